@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/github"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// tokens are refreshed this long before GitHub would expire them
+	appTokenRefreshMargin = 1 * time.Minute
+	// GitHub accepts JWTs that are valid for at most 10 minutes
+	appJwtExpiry = 9 * time.Minute
+)
+
+// appTransport authenticates API calls as a GitHub App installation.
+// It mints a JWT signed with the App's private key, exchanges it for
+// an installation access token, and refreshes that token before it expires.
+type appTransport struct {
+	AppID          int64
+	InstallationID int64
+	Key            *rsa.PrivateKey
+	BaseURL        *url.URL
+	Timeout        time.Duration
+
+	Transport http.RoundTripper
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newAppTransport(appID, installationID int64, keyFile string, timeout time.Duration, transport http.RoundTripper) (*appTransport, error) {
+	contents, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePrivateKey(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appTransport{
+		AppID:          appID,
+		InstallationID: installationID,
+		Key:            key,
+		Timeout:        timeout,
+		Transport:      transport,
+	}, nil
+}
+
+func parsePrivateKey(contents []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, errors.New("no PEM data found in the private key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := parsed.(*rsa.PrivateKey); ok {
+		return key, nil
+	} else {
+		return nil, fmt.Errorf("unexpected private key type: %T", parsed)
+	}
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := cloneRequest(req)
+	authenticated.Header.Set("Authorization", "token "+token)
+
+	return t.transport().RoundTrip(authenticated)
+}
+
+// Token returns a valid installation access token, requesting a new one
+// from the API when there is none yet or the current one is about to expire.
+func (t *appTransport) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Add(appTokenRefreshMargin).Before(t.expiresAt) {
+		return t.token, nil
+	}
+
+	jwt, err := t.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	client := github.NewClient(&http.Client{
+		Transport: &bearerTransport{Token: jwt, Transport: t.transport()},
+		Timeout:   t.Timeout,
+	})
+	if t.BaseURL != nil {
+		client.BaseURL = t.BaseURL
+	}
+
+	installationToken, _, err := client.Apps.CreateInstallationToken(ctx, t.InstallationID)
+	if err != nil {
+		return "", err
+	}
+
+	t.token = installationToken.GetToken()
+	t.expiresAt = installationToken.GetExpiresAt()
+
+	return t.token, nil
+}
+
+func (t *appTransport) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		// allow for some clock drift between us and GitHub
+		"iat": now.Add(-1 * time.Minute).Unix(),
+		"exp": now.Add(appJwtExpiry).Unix(),
+		"iss": strconv.FormatInt(t.AppID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hashed := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.Key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (t *appTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+
+	return http.DefaultTransport
+}
+
+// bearerTransport sends the JWT that identifies the GitHub App itself.
+type bearerTransport struct {
+	Token     string
+	Transport http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authenticated := cloneRequest(req)
+	authenticated.Header.Set("Authorization", "Bearer "+t.Token)
+
+	return t.Transport.RoundTrip(authenticated)
+}
+
+// cloneRequest returns a shallow copy of the request with its own headers,
+// as a RoundTripper should not modify the request it was given.
+func cloneRequest(req *http.Request) *http.Request {
+	clone := new(http.Request)
+	*clone = *req
+
+	clone.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		clone.Header[key] = append([]string(nil), values...)
+	}
+
+	return clone
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppTransportUsesInstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokensIssued := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/installations/42/access_tokens":
+			if r.Method != "POST" {
+				t.Error("Unexpected method:", r.Method)
+			}
+
+			verifyAppJwt(t, r.Header.Get("Authorization"), &key.PublicKey)
+
+			tokensIssued++
+
+			// expire within the refresh margin, so the next call needs a new token
+			fmt.Fprintf(w, `{"token": "v1.token-%d", "expires_at": "%s"}`,
+				tokensIssued, time.Now().Add(30*time.Second).UTC().Format(time.RFC3339))
+
+		case "/user":
+			expected := fmt.Sprintf("token v1.token-%d", tokensIssued)
+			if auth := r.Header.Get("Authorization"); auth != expected {
+				t.Error("Unexpected authorization header:", auth)
+			}
+
+			w.Write([]byte("{}"))
+
+		default:
+			t.Error("Unexpected request:", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+
+	transport := &appTransport{AppID: 1234, InstallationID: 42, Key: key, BaseURL: baseURL}
+	client := &http.Client{Transport: transport}
+
+	for idx := 0; idx < 2; idx++ {
+		if resp, err := client.Get(server.URL + "/user"); err != nil {
+			t.Fatal(err)
+		} else {
+			resp.Body.Close()
+		}
+	}
+
+	if tokensIssued != 2 {
+		t.Error("Expected the token to be refreshed, but it was issued", tokensIssued, "times")
+	}
+}
+
+func TestAppTransportReusesValidToken(t *testing.T) {
+	transport := &appTransport{
+		AppID:          1234,
+		InstallationID: 42,
+		token:          "v1.cached",
+		expiresAt:      time.Now().Add(time.Hour),
+	}
+
+	if token, err := transport.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if token != "v1.cached" {
+		t.Error("Unexpected token:", token)
+	}
+}
+
+func TestAppTransportLoadsPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tf, err := ioutil.TempFile("", "gh-exporter-app-key")
+	if err != nil {
+		t.Fatal("Failed to create a temporary file:", err)
+	}
+	defer os.Remove(tf.Name())
+
+	pem.Encode(tf, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	tf.Close()
+
+	transport, err := newAppTransport(1234, 42, tf.Name(), 5*time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.Key.N.Cmp(key.N) != 0 {
+		t.Error("Unexpected private key loaded")
+	}
+
+	if transport.Timeout != 5*time.Second {
+		t.Error("Unexpected timeout:", transport.Timeout)
+	}
+}
+
+func verifyAppJwt(t *testing.T, header string, key *rsa.PublicKey) {
+	if !strings.HasPrefix(header, "Bearer ") {
+		t.Fatal("Unexpected authorization header:", header)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(header, "Bearer "), ".")
+	if len(parts) != 3 {
+		t.Fatal("Invalid JWT:", header)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature); err != nil {
+		t.Error("Invalid JWT signature:", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if claims["iss"] != "1234" {
+		t.Error("Unexpected issuer:", claims["iss"])
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// RepositoryCollector collects further metrics for each repository
+// with additional API calls. Collectors are opt-in, and are enabled
+// by their name with the -collect flag.
+type RepositoryCollector struct {
+	Name    string
+	Collect func(ctx context.Context, target *Target, repository *github.Repository) error
+	// Forget drops the state kept for a repository that is gone (optional)
+	Forget func(target *Target, owner, repository string)
+}
+
+// OwnerCollector collects further metrics for each user or organization,
+// and is enabled the same way as the repository collectors. Either of the
+// functions can be nil if the collector does not apply to that kind of owner.
+type OwnerCollector struct {
+	Name        string
+	CollectUser func(ctx context.Context, target *Target, user Owner) error
+	CollectOrg  func(ctx context.Context, target *Target, org Owner) error
+}
+
+var (
+	repositoryCollectors []RepositoryCollector
+	ownerCollectors      []OwnerCollector
+)
+
+func addRepositoryCollector(collector RepositoryCollector) {
+	repositoryCollectors = append(repositoryCollectors, collector)
+}
+
+func addOwnerCollector(collector OwnerCollector) {
+	ownerCollectors = append(ownerCollectors, collector)
+}
+
+// collectorNames returns the names of every available collector.
+func collectorNames() []string {
+	var names []string
+
+	for _, collector := range repositoryCollectors {
+		names = append(names, collector.Name)
+	}
+
+	for _, collector := range ownerCollectors {
+		names = append(names, collector.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func isCollector(name string) bool {
+	for _, known := range collectorNames() {
+		if known == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collectRepository runs the collectors enabled for the target on the repository.
+func collectRepository(ctx context.Context, target *Target, repository *github.Repository) {
+	for _, collector := range repositoryCollectors {
+		if !target.collects(collector.Name) {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			// the collection was cancelled
+			return
+		}
+
+		if err := collector.Collect(ctx, target, repository); err != nil {
+			if isPermissionError(err) {
+				// the credentials don't allow this for the repository, which is expected for some collectors
+				continue
+			}
+
+			log.Println("Failed to collect", collector.Name, "metrics for", repository.GetFullName(), ":", err)
+		}
+	}
+}
+
+// forgetRepository removes the metrics of a repository that is gone,
+// and the state the collectors kept for it.
+func forgetRepository(target *Target, owner, repository string) {
+	target.metrics.deleteSeries(prometheus.Labels{"instance": target.Name, "owner": owner, "repository": repository})
+
+	for _, collector := range repositoryCollectors {
+		if collector.Forget != nil {
+			collector.Forget(target, owner, repository)
+		}
+	}
+}
+
+// collectUser runs the collectors enabled for the target on the user.
+func collectUser(ctx context.Context, target *Target, user Owner) {
+	for _, collector := range ownerCollectors {
+		if collector.CollectUser == nil || !target.collects(collector.Name) {
+			continue
+		}
+
+		if err := collector.CollectUser(ctx, target, user); err != nil && !isPermissionError(err) {
+			log.Println("Failed to collect", collector.Name, "metrics for", user.Name, ":", err)
+		}
+	}
+}
+
+// collectOrg runs the collectors enabled for the target on the organization.
+func collectOrg(ctx context.Context, target *Target, org Owner) {
+	for _, collector := range ownerCollectors {
+		if collector.CollectOrg == nil || !target.collects(collector.Name) {
+			continue
+		}
+
+		if err := collector.CollectOrg(ctx, target, org); err != nil && !isPermissionError(err) {
+			log.Println("Failed to collect", collector.Name, "metrics for", org.Name, ":", err)
+		}
+	}
+}
+
+// isPermissionError returns true if the API call was rejected
+// because the credentials don't have access to the resource.
+func isPermissionError(err error) bool {
+	if errResponse, ok := err.(*github.ErrorResponse); ok && errResponse.Response != nil {
+		status := errResponse.Response.StatusCode
+		return status == http.StatusForbidden || status == http.StatusNotFound
+	}
+
+	return false
+}
+
+// countAll pages through a list API, and returns the total number of items.
+func countAll(list func(opts github.ListOptions) (int, *github.Response, error)) (int, error) {
+	total := 0
+
+	opts := github.ListOptions{PerPage: 100}
+
+	for {
+		count, resp, err := list(opts)
+		if err != nil {
+			return 0, err
+		}
+
+		total += count
+
+		if resp.NextPage == 0 {
+			return total, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// repositoryLabels returns the label values identifying the repository.
+func repositoryLabels(target *Target, repository *github.Repository) []string {
+	return []string{target.Name, repository.GetOwner().GetLogin(), repository.GetName()}
+}
+
+// repositorySeries returns the labels identifying the series of the repository.
+func repositorySeries(target *Target, repository *github.Repository) prometheus.Labels {
+	return prometheus.Labels{
+		"instance":   target.Name,
+		"owner":      repository.GetOwner().GetLogin(),
+		"repository": repository.GetName(),
+	}
+}
+
+// boolValue converts the flag into a 1 or 0 gauge value.
+func boolValue(flag bool) float64 {
+	if flag {
+		return 1
+	}
+
+	return 0
+}
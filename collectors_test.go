@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+const testRepositories = `[
+	{"name": "podlike", "full_name": "rycus86/podlike", "owner": {"login": "rycus86"}, "default_branch": "master", "permissions": {"admin": true}},
+	{"name": "forked", "full_name": "rycus86/forked", "owner": {"login": "rycus86"}, "default_branch": "master", "fork": true, "permissions": {"admin": true}}
+]`
+
+// collectWith runs a collection for the rycus86 user of the test repositories,
+// with the given collectors enabled, against the registered mock responses.
+func collectWith(t *testing.T, collectors ...string) {
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		httpmock.NewStringResponder(200, testRepositories))
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Collect: collectors}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+}
+
+// gaugeValue returns the value of the gauge with the given name
+// that has the given labels, and whether it was found.
+func gaugeValue(t *testing.T, name string, labels prometheus.Labels) (float64, bool) {
+	gathered, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, g := range gathered {
+		if g.GetName() != name {
+			continue
+		}
+
+		for _, m := range g.GetMetric() {
+			seriesLabels := prometheus.Labels{}
+			for _, pair := range m.GetLabel() {
+				seriesLabels[pair.GetName()] = pair.GetValue()
+			}
+
+			if hasLabels(seriesLabels, labels) {
+				return m.GetGauge().GetValue(), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// expectGauge fails the test if the gauge with the given name
+// and labels does not have the expected value.
+func expectGauge(t *testing.T, name string, labels prometheus.Labels, expected float64) {
+	if value, found := gaugeValue(t, name, labels); !found {
+		t.Error("Metric not found:", name, labels)
+	} else if value != expected {
+		t.Error("Unexpected value:", name, labels, value, "instead of", expected)
+	}
+}
+
+func TestUnknownCollector(t *testing.T) {
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Collect: []string{"unknown"}}
+
+	if err := target.init(); err == nil {
+		t.Error("Expected an error for the unknown collector")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+)
+
+const (
+	// the preview media type to get the number of required approving reviews
+	mediaTypeRequiredApprovingReviewsPreview = "application/vnd.github.luke-cage-preview+json"
+	// the preview media type of the vulnerability alerts API
+	mediaTypeVulnerabilityAlertsPreview = "application/vnd.github.dorian-preview+json"
+)
+
+var (
+	branchProtectionEnabled = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "branch_protection_enabled",
+		Help:      "Whether the Default Branch is Protected (1) or not (0)",
+	}, []string{"instance", "owner", "repository"})
+	branchProtectionRequiredReviews = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "branch_protection_required_reviews",
+		Help:      "Number of Approving Reviews required to merge into the Default Branch",
+	}, []string{"instance", "owner", "repository"})
+	branchProtectionRequiredStatusChecks = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "branch_protection_required_status_checks",
+		Help:      "Whether Status Checks are required to merge into the Default Branch (1) or not (0)",
+	}, []string{"instance", "owner", "repository"})
+	branchProtectionEnforceAdmins = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "branch_protection_enforce_admins",
+		Help:      "Whether the protection of the Default Branch applies to Administrators (1) or not (0)",
+	}, []string{"instance", "owner", "repository"})
+	vulnerabilityAlertsEnabled = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "repo_vulnerability_alerts_enabled",
+		Help:      "Whether Vulnerability Alerts are enabled for the Repository (1) or not (0)",
+	}, []string{"instance", "owner", "repository"})
+)
+
+// branchProtection adds the fields to the branch protection that
+// the vendored version of go-github does not know about yet.
+type branchProtection struct {
+	github.Protection
+
+	RequiredPullRequestReviews *struct {
+		RequiredApprovingReviewCount int `json:"required_approving_review_count"`
+	} `json:"required_pull_request_reviews"`
+}
+
+func getBranchProtection(ctx context.Context, client *github.Client, owner, repo, branch string) (*branchProtection, error) {
+	req, err := client.NewRequest("GET", fmt.Sprintf("repos/%v/%v/branches/%v/protection", owner, repo, branch), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", mediaTypeRequiredApprovingReviewsPreview)
+
+	protection := new(branchProtection)
+	if _, err := client.Do(ctx, req, protection); err != nil {
+		return nil, err
+	}
+
+	return protection, nil
+}
+
+// hasVulnerabilityAlerts returns whether the vulnerability alerts are
+// enabled for the repository, which GitHub answers with 204 or 404.
+// Without admin access it is always 404, so it is only asked with it.
+func hasVulnerabilityAlerts(ctx context.Context, client *github.Client, owner, repo string) (bool, error) {
+	req, err := client.NewRequest("GET", fmt.Sprintf("repos/%v/%v/vulnerability-alerts", owner, repo), nil)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Accept", mediaTypeVulnerabilityAlertsPreview)
+
+	resp, err := client.Do(ctx, req, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// hasAdminAccess returns whether the credentials listing the repository
+// have admin access to it, as the listing tells.
+func hasAdminAccess(repository *github.Repository) bool {
+	if repository.Permissions == nil {
+		return false
+	}
+
+	return (*repository.Permissions)["admin"]
+}
+
+// collectCompliance collects whether the default branch of the repository
+// is protected, and how. Whether a branch is protected is visible with
+// read access, the details of the protection and the vulnerability alerts
+// need admin access to the repository.
+func collectCompliance(ctx context.Context, target *Target, repository *github.Repository) error {
+	owner, name := repository.GetOwner().GetLogin(), repository.GetName()
+	labels := repositoryLabels(target, repository)
+
+	branch, _, err := target.client.Repositories.GetBranch(ctx, owner, name, repository.GetDefaultBranch())
+	if err != nil {
+		return err
+	}
+
+	target.gauge(branchProtectionEnabled).WithLabelValues(labels...).Set(boolValue(branch.GetProtected()))
+
+	requiredReviews, requiredStatusChecks, enforceAdmins := 0, false, false
+	hasDetails := true
+
+	if branch.GetProtected() {
+		protection, err := getBranchProtection(ctx, target.client, owner, name, branch.GetName())
+		if isPermissionError(err) {
+			// the details of the protection need admin access
+			hasDetails = false
+		} else if err != nil {
+			return err
+		} else {
+			if protection.RequiredPullRequestReviews != nil {
+				requiredReviews = protection.RequiredPullRequestReviews.RequiredApprovingReviewCount
+			}
+
+			requiredStatusChecks = protection.RequiredStatusChecks != nil
+			enforceAdmins = protection.EnforceAdmins != nil && protection.EnforceAdmins.Enabled
+		}
+	}
+
+	if hasDetails {
+		target.gauge(branchProtectionRequiredReviews).WithLabelValues(labels...).Set(float64(requiredReviews))
+		target.gauge(branchProtectionRequiredStatusChecks).WithLabelValues(labels...).Set(boolValue(requiredStatusChecks))
+		target.gauge(branchProtectionEnforceAdmins).WithLabelValues(labels...).Set(boolValue(enforceAdmins))
+	} else {
+		target.gauge(branchProtectionRequiredReviews).DeleteLabelValues(labels...)
+		target.gauge(branchProtectionRequiredStatusChecks).DeleteLabelValues(labels...)
+		target.gauge(branchProtectionEnforceAdmins).DeleteLabelValues(labels...)
+	}
+
+	if !hasAdminAccess(repository) {
+		target.gauge(vulnerabilityAlertsEnabled).DeleteLabelValues(labels...)
+		return nil
+	}
+
+	alerts, err := hasVulnerabilityAlerts(ctx, target.client, owner, name)
+	if err != nil {
+		return err
+	}
+
+	target.gauge(vulnerabilityAlertsEnabled).WithLabelValues(labels...).Set(boolValue(alerts))
+
+	return nil
+}
+
+func init() {
+	register(branchProtectionEnabled)
+	register(branchProtectionRequiredReviews)
+	register(branchProtectionRequiredStatusChecks)
+	register(branchProtectionEnforceAdmins)
+	register(vulnerabilityAlertsEnabled)
+
+	addRepositoryCollector(RepositoryCollector{Name: "compliance", Collect: collectCompliance})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+func TestCollectCompliance(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/branches/master",
+		httpmock.NewStringResponder(200, `{"name": "master", "protected": true}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/branches/master/protection",
+		httpmock.NewStringResponder(200, `{
+			"required_status_checks": {"strict": true, "contexts": ["ci"]},
+			"required_pull_request_reviews": {"required_approving_review_count": 2},
+			"enforce_admins": {"enabled": true}
+		}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/vulnerability-alerts",
+		httpmock.NewStringResponder(204, ``))
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/branches/master",
+		httpmock.NewStringResponder(200, `{"name": "master", "protected": false}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/vulnerability-alerts",
+		httpmock.NewStringResponder(404, `{"message": "Not Found"}`))
+
+	collectWith(t, "compliance")
+
+	podlike := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_branch_protection_enabled", podlike, 1)
+	expectGauge(t, "github_branch_protection_required_reviews", podlike, 2)
+	expectGauge(t, "github_branch_protection_required_status_checks", podlike, 1)
+	expectGauge(t, "github_branch_protection_enforce_admins", podlike, 1)
+	expectGauge(t, "github_repo_vulnerability_alerts_enabled", podlike, 1)
+
+	forked := prometheus.Labels{"owner": "rycus86", "repository": "forked"}
+
+	expectGauge(t, "github_branch_protection_enabled", forked, 0)
+	expectGauge(t, "github_branch_protection_required_reviews", forked, 0)
+	expectGauge(t, "github_branch_protection_enforce_admins", forked, 0)
+	expectGauge(t, "github_repo_vulnerability_alerts_enabled", forked, 0)
+}
+
+func TestCollectComplianceWithoutAdminAccess(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/branches/master",
+		httpmock.NewStringResponder(200, `{"name": "master", "protected": true}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/branches/master/protection",
+		httpmock.NewStringResponder(404, `{"message": "Not Found"}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/vulnerability-alerts",
+		httpmock.NewStringResponder(204, ``))
+
+	podlike := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	// from a previous collection, with admin access
+	branchProtectionRequiredReviews.WithLabelValues("api.github.com", "rycus86", "podlike").Set(2)
+
+	collectWith(t, "compliance")
+
+	expectGauge(t, "github_branch_protection_enabled", podlike, 1)
+	expectGauge(t, "github_repo_vulnerability_alerts_enabled", podlike, 1)
+
+	for _, name := range []string{
+		"github_branch_protection_required_reviews",
+		"github_branch_protection_required_status_checks",
+		"github_branch_protection_enforce_admins",
+	} {
+		if _, found := gaugeValue(t, name, podlike); found {
+			t.Error("Unexpected protection details without admin access:", name)
+		}
+	}
+}
+
+func TestCollectComplianceWithoutAdminPermission(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/users/rycus86/repos",
+		httpmock.NewStringResponder(200, `[{
+			"name": "podlike", "owner": {"login": "rycus86"}, "default_branch": "master",
+			"permissions": {"admin": false, "push": true, "pull": true}
+		}]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/branches/master",
+		httpmock.NewStringResponder(200, `{"name": "master", "protected": false}`))
+
+	podlike := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	// from a previous collection, with admin access
+	vulnerabilityAlertsEnabled.WithLabelValues("api.github.com", "rycus86", "podlike").Set(1)
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Collect: []string{"compliance"}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	expectGauge(t, "github_branch_protection_enabled", podlike, 0)
+
+	if _, found := gaugeValue(t, "github_repo_vulnerability_alerts_enabled", podlike); found {
+		t.Error("Unexpected vulnerability alerts without admin access")
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config is the schema of the configuration file given with -config.
+// The top level accepts the same settings as the command line flags,
+// and the owners listed there are collected from the target they describe.
+// Further targets can be listed under the targets key.
+type Config struct {
+	Target
+
+	Port     int       `json:"port"`
+	Snapshot *bool     `json:"snapshot"`
+	Targets  []*Target `json:"targets"`
+}
+
+// Owner is a user or organization to collect metrics for,
+// optionally with its own settings overriding the ones of its target.
+type Owner struct {
+	Name      string `json:"name"`
+	SkipForks *bool  `json:"skip_forks"`
+}
+
+// UnmarshalJSON accepts either the name of the owner as a string,
+// or an object with the name and the overrides.
+func (o *Owner) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &o.Name)
+	}
+
+	type plain Owner
+	return json.Unmarshal(data, (*plain)(o))
+}
+
+func ownersOf(names []string) []Owner {
+	var owners []Owner
+
+	for _, name := range names {
+		owners = append(owners, Owner{Name: name})
+	}
+
+	return owners
+}
+
+var (
+	targetSchema = map[string]string{
+		"name":                   "string",
+		"api_url":                "string",
+		"upload_url":             "string",
+		"ca_file":                "string",
+		"insecure_skip_verify":   "bool",
+		"username":               "string",
+		"password":               "string",
+		"credentials":            "string",
+		"token":                  "string",
+		"token_file":             "string",
+		"app_id":                 "int",
+		"app_installation_id":    "int",
+		"app_key":                "string",
+		"users":                  "owners",
+		"orgs":                   "owners",
+		"skip_forks":             "bool",
+		"interval":               "positive duration",
+		"timeout":                "positive duration",
+		"stale_grace_period":     "duration",
+		"collect":                "strings",
+		"issue_labels":           "strings",
+		"top_contributors":       "int",
+		"concurrency":            "int",
+		"repository_concurrency": "int",
+	}
+
+	configSchema = withKeys(targetSchema, map[string]string{
+		"port":     "int",
+		"snapshot": "bool",
+		"targets":  "targets",
+	})
+
+	ownerSchema = map[string]string{
+		"name":       "string",
+		"skip_forks": "bool",
+	}
+)
+
+func withKeys(schema map[string]string, extra map[string]string) map[string]string {
+	merged := map[string]string{}
+
+	for key, kind := range schema {
+		merged[key] = kind
+	}
+
+	for key, kind := range extra {
+		merged[key] = kind
+	}
+
+	return merged
+}
+
+// loadConfig reads and validates the configuration file.
+func loadConfig(path string) (*Config, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := decodeJSON(contents, &raw); err != nil {
+		return nil, err
+	}
+
+	if err := validate("", raw, "config"); err != nil {
+		return nil, err
+	}
+
+	config := new(Config)
+	if err := json.Unmarshal(contents, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// applyConfig uses the settings from the configuration file
+// for every flag that was not given on the command line.
+func applyConfig(config *Config) {
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	// start from the defaults, in case a setting was removed from the file since it was last applied
+	flag.VisitAll(func(f *flag.Flag) {
+		if _, isList := f.Value.(*multiVar); !isList && !given[f.Name] {
+			f.Value.Set(f.DefValue)
+		}
+	})
+
+	setInt := func(name string, target *int, value int) {
+		if !given[name] && value != 0 {
+			*target = value
+		}
+	}
+	setInt64 := func(name string, target *int64, value int64) {
+		if !given[name] && value != 0 {
+			*target = value
+		}
+	}
+	setString := func(name string, target *string, value string) {
+		if !given[name] && value != "" {
+			*target = value
+		}
+	}
+	setDuration := func(name string, target *time.Duration, value Duration) {
+		if !given[name] && value.Duration != 0 {
+			*target = value.Duration
+		}
+	}
+	setBool := func(name string, target *bool, value *bool) {
+		if !given[name] && value != nil {
+			*target = *value
+		}
+	}
+
+	setInt("port", port, config.Port)
+	setBool("snapshot", snapshotMode, config.Snapshot)
+	setDuration("interval", interval, config.Interval)
+	setDuration("timeout", timeout, config.Timeout)
+	setDuration("stale-grace-period", staleGracePeriod, config.StaleGracePeriod)
+	setBool("skip-forks", skipForks, config.SkipForks)
+	setInt("top-contributors", topContributors, config.TopContributors)
+	setInt("concurrency", concurrency, config.Concurrency)
+	setInt("repository-concurrency", repositoryConcurrency, config.RepositoryConcurrency)
+
+	setString("api-url", apiURL, config.APIURL)
+	setString("upload-url", uploadURL, config.UploadURL)
+	setString("ca-file", caFile, config.CAFile)
+	setBool("insecure-skip-verify", insecureSkipVerify, &config.InsecureSkipVerify)
+
+	setString("username", usernameVar, config.Username)
+	setString("password", passwordVar, config.Password)
+	setString("credentials", credentialsFile, config.CredentialsFile)
+	setString("token", tokenVar, config.Token)
+	setString("token-file", tokenFile, config.TokenFile)
+	setInt64("app-id", appID, config.AppID)
+	setInt64("app-installation-id", appInstallationID, config.AppInstallationID)
+	setString("app-key", appKeyFile, config.AppKeyFile)
+}
+
+// configuredTargets loads the configuration file and the targets file,
+// if any, and returns the initialized targets to collect metrics from.
+func configuredTargets() ([]*Target, error) {
+	var targets, additional []*Target
+
+	defaultTarget := flagTarget()
+
+	if *configFile != "" {
+		config, err := loadConfig(*configFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration file %s: %s", *configFile, err)
+		}
+
+		applyConfig(config)
+
+		defaultTarget = configTarget(config)
+		additional = append(additional, config.Targets...)
+	}
+
+	if *targetsFile != "" {
+		if loaded, err := loadTargets(*targetsFile); err != nil {
+			return nil, fmt.Errorf("failed to load the targets file %s: %s", *targetsFile, err)
+		} else {
+			additional = append(additional, loaded...)
+		}
+	}
+
+	// the collector settings of the default target apply to every other target by default
+	for _, target := range additional {
+		if target.Collect == nil {
+			target.Collect = defaultTarget.Collect
+		}
+
+		if target.IssueLabels == nil {
+			target.IssueLabels = defaultTarget.IssueLabels
+		}
+	}
+
+	if len(defaultTarget.Users) > 0 || len(defaultTarget.Orgs) > 0 {
+		targets = append(targets, defaultTarget)
+	}
+
+	targets = append(targets, additional...)
+
+	// without any owners, the default target is still used by the probes
+	if len(targets) == 0 {
+		targets = append(targets, defaultTarget)
+	}
+
+	names := map[string]bool{}
+
+	for _, target := range targets {
+		if err := target.init(); err != nil {
+			return nil, fmt.Errorf("invalid configuration for %s: %s", target.Name, err)
+		}
+
+		if names[target.Name] {
+			return nil, fmt.Errorf("duplicate target name: %s", target.Name)
+		}
+
+		names[target.Name] = true
+	}
+
+	return targets, nil
+}
+
+// configTarget returns the target described by the top level of the
+// configuration file, merged with the command line flags.
+func configTarget(config *Config) *Target {
+	target := flagTarget()
+	target.Name = config.Name
+
+	target.Users = mergeOwners(config.Users, users)
+	target.Orgs = mergeOwners(config.Orgs, orgs)
+	target.Collect = mergeNames(config.Collect, collect)
+	target.IssueLabels = mergeNames(config.IssueLabels, issueLabels)
+
+	return target
+}
+
+func mergeOwners(configured []Owner, names []string) []Owner {
+	merged := append([]Owner(nil), configured...)
+
+	for _, owner := range ownersOf(names) {
+		found := false
+
+		for _, existing := range configured {
+			if existing.Name == owner.Name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			merged = append(merged, owner)
+		}
+	}
+
+	return merged
+}
+
+func mergeNames(configured []string, names []string) []string {
+	merged := append([]string(nil), configured...)
+
+	for _, name := range names {
+		found := false
+
+		for _, existing := range configured {
+			if existing == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			merged = append(merged, name)
+		}
+	}
+
+	return merged
+}
+
+func decodeJSON(contents []byte, value interface{}) error {
+	err := json.Unmarshal(contents, value)
+
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		line := bytes.Count(contents[:syntaxErr.Offset], []byte("\n")) + 1
+		return fmt.Errorf("line %d: %s", line, syntaxErr)
+	}
+
+	return err
+}
+
+// validate checks the decoded value against the expected kind,
+// and returns an error pointing to the offending key if it does not match.
+func validate(path string, value interface{}, kind string) error {
+	switch kind {
+	case "config":
+		return validateObject(path, value, configSchema)
+
+	case "target":
+		return validateObject(path, value, targetSchema)
+
+	case "owner":
+		if _, ok := value.(string); ok {
+			return nil
+		}
+
+		if err := validateObject(path, value, ownerSchema); err != nil {
+			return err
+		}
+
+		if name, _ := value.(map[string]interface{})["name"].(string); name == "" {
+			return fmt.Errorf("%s: the name of the owner is required", path)
+		}
+
+	case "owners", "targets", "strings":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a list", path)
+		}
+
+		for idx, item := range items {
+			if err := validate(fmt.Sprintf("%s[%d]", path, idx), item, strings.TrimSuffix(kind, "s")); err != nil {
+				return err
+			}
+		}
+
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected a string", path)
+		}
+
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected true or false", path)
+		}
+
+	case "int":
+		if number, ok := value.(float64); !ok || number != float64(int64(number)) {
+			return fmt.Errorf("%s: expected a whole number", path)
+		}
+
+	case "duration", "positive duration":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a duration like \"15m\"", path)
+		}
+
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+
+		if kind == "positive duration" && parsed <= 0 {
+			return fmt.Errorf("%s: expected a positive duration", path)
+		} else if parsed < 0 {
+			return fmt.Errorf("%s: expected a duration that is not negative", path)
+		}
+	}
+
+	return nil
+}
+
+func validateObject(path string, value interface{}, schema map[string]string) error {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		if path == "" {
+			return fmt.Errorf("expected an object at the top level")
+		}
+
+		return fmt.Errorf("%s: expected an object", path)
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		kind, known := schema[key]
+		if !known {
+			return fmt.Errorf("%s: unknown key", keyPath)
+		}
+
+		if err := validate(keyPath, object[key], kind); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	tf, err := ioutil.TempFile("", "gh-exporter-config")
+	if err != nil {
+		t.Fatal("Failed to create a temporary file:", err)
+	}
+
+	tf.WriteString(contents)
+	tf.Close()
+
+	return tf.Name()
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `{
+		"port": 9090,
+		"interval": "10m",
+		"token": "t0k3n",
+		"users": ["rycus86"],
+		"orgs": ["docker", {"name": "moby", "skip_forks": true}],
+		"targets": [
+			{"name": "ghe", "api_url": "https://github.example.com/api/v3/", "orgs": ["platform"]}
+		]
+	}`)
+	defer os.Remove(path)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Port != 9090 || config.Interval.Duration != 10*time.Minute || config.Token != "t0k3n" {
+		t.Errorf("Unexpected top level settings: %+v", config)
+	}
+
+	if len(config.Orgs) != 2 || config.Orgs[0].Name != "docker" || config.Orgs[0].SkipForks != nil {
+		t.Errorf("Unexpected organizations: %+v", config.Orgs)
+	}
+
+	if config.Orgs[1].Name != "moby" || config.Orgs[1].SkipForks == nil || !*config.Orgs[1].SkipForks {
+		t.Errorf("Unexpected owner override: %+v", config.Orgs[1])
+	}
+
+	if len(config.Targets) != 1 || config.Targets[0].Name != "ghe" || config.Targets[0].Orgs[0].Name != "platform" {
+		t.Errorf("Unexpected targets: %+v", config.Targets)
+	}
+}
+
+func TestInvalidConfigPointsToTheKey(t *testing.T) {
+	tests := map[string]string{
+		`{"port": "8080"}`:                            "port: expected a whole number",
+		`{"intervall": "5m"}`:                         "intervall: unknown key",
+		`{"orgs": ["docker", {"skip_forks": true}]}`:  "orgs[1]: the name of the owner is required",
+		`{"orgs": [{"name": "moby", "skip": true}]}`:  "orgs[0].skip: unknown key",
+		`{"targets": [{}, {"timeout": "soon"}]}`:      "targets[1].timeout: ",
+		`{"targets": [{"skip_forks": "yes"}]}`:        "targets[0].skip_forks: expected true or false",
+		`{"users": "rycus86"}`:                        "users: expected a list",
+		`{"interval": "-5m"}`:                         "interval: expected a positive duration",
+		`{"targets": [{"timeout": "0s"}]}`:            "targets[0].timeout: expected a positive duration",
+		`{"stale_grace_period": "-1h"}`:               "stale_grace_period: expected a duration that is not negative",
+		"{\n  \"users\": [\n    \"rycus86\",\n  ]\n}": "line 4: ",
+	}
+
+	for contents, expected := range tests {
+		path := writeConfig(t, contents)
+		defer os.Remove(path)
+
+		if _, err := loadConfig(path); err == nil {
+			t.Error("Expected an error for", contents)
+		} else if !strings.HasPrefix(err.Error(), expected) {
+			t.Errorf("Unexpected error for %s: %s", contents, err)
+		}
+	}
+}
+
+func TestFlagsOverrideConfig(t *testing.T) {
+	originalInterval, originalTimeout := *interval, *timeout
+	defer func() {
+		*interval = originalInterval
+		*timeout = originalTimeout
+		*tokenVar = ""
+		users = multiVar{}
+	}()
+
+	flag.Set("interval", "1m")
+	flag.Set("user", "rycus86")
+
+	applyConfig(&Config{Target: Target{
+		Interval: Duration{5 * time.Minute},
+		Timeout:  Duration{time.Minute},
+		Token:    "t0k3n",
+		Users:    []Owner{{Name: "docker"}},
+	}})
+
+	if *interval != time.Minute {
+		t.Error("The flag should take precedence:", *interval)
+	}
+
+	if *timeout != time.Minute || *tokenVar != "t0k3n" {
+		t.Error("The configuration should be applied:", *timeout, *tokenVar)
+	}
+
+	target := configTarget(&Config{Target: Target{Users: []Owner{{Name: "docker"}, {Name: "rycus86"}}}})
+
+	if len(target.Users) != 2 || target.Users[0].Name != "docker" || target.Users[1].Name != "rycus86" {
+		t.Errorf("Unexpected users: %+v", target.Users)
+	}
+}
+
+func TestTargetsDoNotShareTheFlags(t *testing.T) {
+	original := *skipForks
+	defer func() {
+		*skipForks = original
+	}()
+
+	*skipForks = false
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a reload applying the configuration file changes the flags
+	skip := true
+	applyConfig(&Config{Target: Target{SkipForks: &skip}})
+
+	if *target.SkipForks {
+		t.Error("The settings of the running targets should not change")
+	}
+}
+
+func TestCollectStatsWithOwnerOverride(t *testing.T) {
+	repoCount.Reset()
+
+	pageOne, err := ioutil.ReadFile("testdata/repos_p1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pageTwo, err := ioutil.ReadFile("testdata/repos_p2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("page") == "2" {
+				return httpmock.NewBytesResponse(200, pageTwo), nil
+			}
+
+			resp := httpmock.NewBytesResponse(200, pageOne)
+			resp.Header.Set("Link", "<https://api.github.com/users/rycus86/repos?page=2>; rel=\"next\"")
+			return resp, nil
+		})
+
+	skip := true
+	target := &Target{Users: []Owner{{Name: "rycus86", SkipForks: &skip}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	gathered, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, g := range gathered {
+		if g.GetName() != "github_repo_count" {
+			continue
+		}
+
+		for _, m := range g.GetMetric() {
+			if value := m.GetGauge().GetValue(); value != 52 {
+				t.Error("Unexpected number of repositories without forks:", value)
+			}
+		}
+	}
+}
+
+func TestTargetsInheritTheCollectorSettings(t *testing.T) {
+	configPath := writeConfig(t, `{
+		"collect": ["pulls"],
+		"issue_labels": ["bug"],
+		"targets": [{"name": "ghe", "api_url": "https://github.example.com/api/v3/", "orgs": ["platform"]}]
+	}`)
+	defer os.Remove(configPath)
+
+	targetsPath := writeConfig(t, `[
+		{"name": "public", "users": ["rycus86"]},
+		{"name": "other", "users": ["docker"], "collect": ["stats"], "issue_labels": []}
+	]`)
+	defer os.Remove(targetsPath)
+
+	flag.Set("config", configPath)
+	flag.Set("targets", targetsPath)
+	defer func() { *configFile, *targetsFile = "", "" }()
+
+	targets, err := configuredTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collectors := map[string]string{}
+	labels := map[string]string{}
+
+	for _, target := range targets {
+		collectors[target.Name] = strings.Join(target.Collect, ",")
+		labels[target.Name] = strings.Join(target.IssueLabels, ",")
+	}
+
+	for _, name := range []string{"ghe", "public"} {
+		if collectors[name] != "pulls" || labels[name] != "bug" {
+			t.Errorf("The settings at the top level should apply to %s: %s %s", name, collectors[name], labels[name])
+		}
+	}
+
+	if collectors["other"] != "stats" || labels["other"] != "" {
+		t.Error("The settings of the target should take precedence:", collectors["other"], labels["other"])
+	}
+}
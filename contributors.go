@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"log"
+	"sort"
+	"time"
+)
+
+// the period to calculate the bus factor for
+const busFactorPeriod = 90 * 24 * time.Hour
+
+var (
+	contributorsCount = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "contributors_count",
+		Help:      "Number of Contributors",
+	}, []string{"instance", "owner", "repository"})
+	contributorCommits = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "contributor_commits",
+		Help:      "Number of Commits by the Top Contributors",
+	}, []string{"instance", "owner", "repository", "author"})
+	contributorsBusFactor = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "contributors_bus_factor",
+		Help:      "Minimum number of Authors with 50% of the Commits in the last 90 days",
+	}, []string{"instance", "owner", "repository"})
+)
+
+func collectContributors(ctx context.Context, target *Target, repository *github.Repository) error {
+	contributors, _, err := target.client.Repositories.ListContributorsStats(
+		ctx, repository.GetOwner().GetLogin(), repository.GetName())
+	if isPendingStatistics(err) {
+		log.Println("Statistics for", repository.GetFullName(), "are being computed, retrying on the next collection")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	labels := repositoryLabels(target, repository)
+
+	target.gauge(contributorsCount).WithLabelValues(labels...).Set(float64(len(contributors)))
+
+	// sort by the total number of commits, largest first
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return contributors[i].GetTotal() > contributors[j].GetTotal()
+	})
+
+	commits := target.updateSeries(contributorCommits, repositorySeries(target, repository))
+
+	for idx, contributor := range contributors {
+		if idx >= target.TopContributors {
+			break
+		}
+
+		commits.WithLabelValues(append(labels, contributor.GetAuthor().GetLogin())...).
+			Set(float64(contributor.GetTotal()))
+	}
+
+	commits.done()
+
+	target.gauge(contributorsBusFactor).WithLabelValues(labels...).Set(float64(busFactor(contributors, time.Now().Add(-busFactorPeriod))))
+
+	return nil
+}
+
+// busFactor returns the minimum number of authors
+// who made at least half of the commits since the given time.
+func busFactor(contributors []*github.ContributorStats, since time.Time) int {
+	var (
+		commits []int
+		total   int
+	)
+
+	for _, contributor := range contributors {
+		count := 0
+
+		for _, week := range contributor.Weeks {
+			if week.Week != nil && !week.Week.Before(since) {
+				count += week.GetCommits()
+			}
+		}
+
+		if count > 0 {
+			commits = append(commits, count)
+			total += count
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(commits)))
+
+	authors, sum := 0, 0
+
+	for _, count := range commits {
+		if sum*2 >= total {
+			break
+		}
+
+		authors++
+		sum += count
+	}
+
+	return authors
+}
+
+func init() {
+	register(contributorsCount)
+	register(contributorCommits)
+	register(contributorsBusFactor)
+
+	addRepositoryCollector(RepositoryCollector{Name: "contributors", Collect: collectContributors})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+	"time"
+)
+
+func TestCollectContributors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	recent := time.Now().Add(-14 * 24 * time.Hour).Unix()
+	old := time.Now().Add(-200 * 24 * time.Hour).Unix()
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/stats/contributors",
+		httpmock.NewStringResponder(200, fmt.Sprintf(`[
+			{"author": {"login": "occasional"}, "total": 3, "weeks": [{"w": %[1]d, "c": 3}]},
+			{"author": {"login": "rycus86"}, "total": 250, "weeks": [{"w": %[2]d, "c": 200}, {"w": %[1]d, "c": 10}]},
+			{"author": {"login": "helper"}, "total": 40, "weeks": [{"w": %[2]d, "c": 32}, {"w": %[1]d, "c": 8}]}
+		]`, recent, old)))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/stats/contributors",
+		httpmock.NewStringResponder(202, `{}`))
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		httpmock.NewStringResponder(200, testRepositories))
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Collect: []string{"contributors"}, TopContributors: 2}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	labels := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_contributors_count", labels, 3)
+	expectGauge(t, "github_contributor_commits", prometheus.Labels{"repository": "podlike", "author": "rycus86"}, 250)
+	expectGauge(t, "github_contributor_commits", prometheus.Labels{"repository": "podlike", "author": "helper"}, 40)
+	// 10 of the 21 recent commits are from rycus86, so another author is needed for half of them
+	expectGauge(t, "github_contributors_bus_factor", labels, 2)
+
+	if _, found := gaugeValue(t, "github_contributor_commits", prometheus.Labels{"author": "occasional"}); found {
+		t.Error("Unexpected metric for a contributor outside the top 2")
+	}
+
+	if _, found := gaugeValue(t, "github_contributors_count", prometheus.Labels{"repository": "forked"}); found {
+		t.Error("Unexpected contributors while the statistics are being computed")
+	}
+}
+
+func TestBusFactor(t *testing.T) {
+	week := func(commits int) github.WeeklyStats {
+		return github.WeeklyStats{Week: &github.Timestamp{Time: time.Now()}, Commits: github.Int(commits)}
+	}
+
+	tests := []struct {
+		commits  []int
+		expected int
+	}{
+		{[]int{}, 0},
+		{[]int{10}, 1},
+		{[]int{5, 5}, 1},
+		{[]int{4, 3, 3}, 2},
+		{[]int{1, 1, 1, 1, 1}, 3},
+	}
+
+	for _, test := range tests {
+		var contributors []*github.ContributorStats
+
+		for _, commits := range test.commits {
+			contributors = append(contributors, &github.ContributorStats{Weeks: []github.WeeklyStats{week(commits)}})
+		}
+
+		if actual := busFactor(contributors, time.Now().Add(-time.Hour)); actual != test.expected {
+			t.Error("Unexpected bus factor for", test.commits, ":", actual, "instead of", test.expected)
+		}
+	}
+}
@@ -3,134 +3,149 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
 	"github.com/google/go-github/github"
-	"github.com/gregjones/httpcache"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 )
 
 func main() {
-	if len(users) == 0 && len(orgs) == 0 {
-		fmt.Println("Usage:")
-		flag.PrintDefaults()
-		fmt.Println()
+	flag.Parse()
 
-		log.Fatal("No users or organizations were defined")
+	targets, err := configuredTargets()
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	client := github.NewClient(getApiClient())
+	if !hasOwners(targets) {
+		log.Println("No users or organizations were defined, metrics are only available from the /probe endpoint")
+	}
 
-	go func() {
-		firstRun := time.After(0 * time.Second)
+	if *snapshotMode {
+		enableSnapshots()
+	}
 
-		for {
-			select {
-			case <-firstRun:
-				collectStats(client)
+	startTargets(targets)
 
-			case <-time.Tick(*interval):
-				collectStats(client)
-			}
-		}
-	}()
+	go reloadOnSignal()
 
-	http.Handle("/metrics", promhttp.Handler())
+	if *onDemandMode {
+		http.Handle("/metrics", onDemandHandler(promhttp.Handler()))
+	} else {
+		http.Handle("/metrics", promhttp.Handler())
+	}
+
+	http.HandleFunc("/probe", probeHandler)
+	http.HandleFunc("/-/reload", reloadHandler)
 	log.Fatal(http.ListenAndServe(":"+strconv.Itoa(*port), nil))
 }
 
-func getApiClient() *http.Client {
-	var (
-		username string
-		password string
-	)
-
-	if *credentialsFile != "" {
-		if contents, err := ioutil.ReadFile(*credentialsFile); err != nil {
-			log.Fatalln("Failed to read the credentials file:", err)
-		} else {
-			parts := strings.SplitN(string(contents), ":", 2)
-			username = strings.TrimSpace(parts[0])
-			password = strings.TrimSpace(parts[1])
+func hasOwners(targets []*Target) bool {
+	for _, target := range targets {
+		if len(target.owners()) > 0 {
+			return true
 		}
-	} else if *usernameVar != "" && *passwordVar != "" {
-		username = *usernameVar
-		password = *passwordVar
 	}
 
-	if username != "" && password != "" {
-		return &http.Client{
-			Transport: &github.BasicAuthTransport{
-				Username:  username,
-				Password:  password,
-				Transport: httpcache.NewMemoryCacheTransport(),
-			},
-			Timeout: *timeout,
-		}
-	} else {
-		return &http.Client{
-			Transport: httpcache.NewMemoryCacheTransport(),
-			Timeout:   *timeout,
-		}
-	}
+	return false
 }
 
-func collectStats(client *github.Client) {
-	for _, user := range users {
-		log.Println("Collecting metrics for", user)
+// collectStats collects the metrics of every owner of the target, with the
+// owners and the additional requests for repositories running concurrently,
+// up to the limits set for the target.
+func collectStats(ctx context.Context, target *Target) {
+	client := target.client
 
-		collectStatsFor(user,
-			func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
-				return client.Repositories.List(
-					context.Background(), user, &github.RepositoryListOptions{ListOptions: opts})
-			})
+	owners := newWorkerPool(target.Concurrency)
+	repositories := newWorkerPool(target.RepositoryConcurrency)
+
+	for _, user := range target.Users {
+		user := user
+
+		owners.run(func() {
+			log.Println("Collecting metrics for", user.Name, "from", target.Name)
+
+			collectStatsFor(ctx, target, user, repositories,
+				func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+					return client.Repositories.List(
+						ctx, user.Name, &github.RepositoryListOptions{ListOptions: opts})
+				})
+
+			collectUser(ctx, target, user)
+		})
 	}
 
-	for _, org := range orgs {
-		log.Println("Collecting metrics for", org)
+	for _, org := range target.Orgs {
+		org := org
 
-		collectStatsFor(org,
-			func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
-				return client.Repositories.ListByOrg(
-					context.Background(), org, &github.RepositoryListByOrgOptions{ListOptions: opts})
-			})
+		owners.run(func() {
+			log.Println("Collecting metrics for", org.Name, "from", target.Name)
+
+			collectStatsFor(ctx, target, org, repositories,
+				func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+					return client.Repositories.ListByOrg(
+						ctx, org.Name, &github.RepositoryListByOrgOptions{ListOptions: opts})
+				})
+
+			collectOrg(ctx, target, org)
+		})
 	}
+
+	owners.wait()
+	repositories.wait()
 }
 
-func collectStatsFor(owner string, listFunc func(github.ListOptions) ([]*github.Repository, *github.Response, error)) {
+func collectStatsFor(ctx context.Context, target *Target, owner Owner, repositories *workerPool, listFunc func(github.ListOptions) ([]*github.Repository, *github.Response, error)) {
 	totalCount := 0
+	skipForks := target.skipsForks(owner)
+	seen := map[string]bool{}
+
+	// the series are labelled with the login from the API, which may differ in case from the configured name
+	login := target.loginOf(owner.Name)
 
 	opts := github.ListOptions{PerPage: 100}
 
 	for {
-		repos, resp, err := listFunc(opts) // client.Repositories.List(context.Background(), user, opts)
+		repos, resp, err := listFunc(opts)
 		if err != nil {
-			log.Println("Failed to fetch page ", opts.Page, " of the repos for ", owner, ": ", err)
+			log.Println("Failed to fetch page ", opts.Page, " of the repos for ", owner.Name, ": ", err)
 			return
 		}
 
 		// update rate limit related metrics
-		rateLimit.Set(float64(resp.Rate.Limit))
-		rateRemaining.Set(float64(resp.Rate.Remaining))
-		rateReset.Set(float64(resp.Reset.UnixNano() / time.Millisecond.Nanoseconds()))
+		target.gauge(rateLimit).WithLabelValues(target.Name).Set(float64(resp.Rate.Limit))
+		target.gauge(rateRemaining).WithLabelValues(target.Name).Set(float64(resp.Rate.Remaining))
+		target.gauge(rateReset).WithLabelValues(target.Name).Set(float64(resp.Reset.UnixNano() / time.Millisecond.Nanoseconds()))
 
 		// update the repo metrics
 		for _, repo := range repos {
-			if *skipForks && repo.GetFork() {
+			if ctx.Err() != nil {
+				log.Println("Stopped collecting the repos for", owner.Name, ":", ctx.Err())
+				return
+			}
+
+			login = repo.GetOwner().GetLogin()
+
+			if skipForks && repo.GetFork() {
 				continue
 			}
 
 			// keep track of the total number of repos
 			totalCount += 1
+			seen[repo.GetName()] = true
 
 			for _, m := range metrics {
-				m.Update(repo)
+				m.Update(target, repo)
 			}
+
+			updateInfo(target, repo)
+
+			repo := repo
+			repositories.run(func() {
+				collectRepository(ctx, target, repo)
+			})
 		}
 
 		if resp.NextPage == 0 {
@@ -140,5 +155,12 @@ func collectStatsFor(owner string, listFunc func(github.ListOptions) ([]*github.
 		opts.Page = resp.NextPage
 	}
 
-	repoCount.WithLabelValues(owner).Set(float64(totalCount))
+	target.gauge(repoCount).WithLabelValues(target.Name, owner.Name).Set(float64(totalCount))
+
+	// the repositories not listed for a while were deleted, renamed or transferred
+	for _, repository := range target.markSeen(login, seen, time.Now()) {
+		log.Println("Removing the metrics of", login+"/"+repository, "from", target.Name)
+
+		forgetRepository(target, login, repository)
+	}
 }
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"encoding/pem"
+	"fmt"
 	"github.com/google/go-github/github"
 	"github.com/gregjones/httpcache"
 	"github.com/prometheus/client_golang/prometheus"
@@ -8,9 +11,11 @@ import (
 	"gopkg.in/jarcoal/httpmock.v1"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCollectStatsForUser(t *testing.T) {
@@ -20,8 +25,10 @@ func TestCollectStatsForUser(t *testing.T) {
 		m.gauge.Reset()
 	}
 
-	users = multiVar([]string{"rycus86"})
-	orgs = multiVar{}
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
 
 	data, err := ioutil.ReadFile("testdata/repos_p1.json")
 	if err != nil {
@@ -50,7 +57,7 @@ func TestCollectStatsForUser(t *testing.T) {
 			}
 		})
 
-	collectStats(github.NewClient(nil))
+	collectStats(context.Background(), target)
 
 	gathered, err := prometheus.DefaultGatherer.Gather()
 	if err != nil {
@@ -107,13 +114,16 @@ func TestCollectStatsForUser(t *testing.T) {
 
 func TestCollectStatsForOrg(t *testing.T) {
 	repoCount.Reset()
+	repoInfo.Reset()
 
 	for _, m := range metrics {
 		m.gauge.Reset()
 	}
 
-	users = multiVar{}
-	orgs = multiVar([]string{"docker"})
+	target := &Target{Orgs: []Owner{{Name: "docker"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
 
 	data, err := ioutil.ReadFile("testdata/org_repos.json")
 	if err != nil {
@@ -127,7 +137,7 @@ func TestCollectStatsForOrg(t *testing.T) {
 		"GET", "https://api.github.com/orgs/docker/repos",
 		httpmock.NewBytesResponder(200, data))
 
-	collectStats(github.NewClient(nil))
+	collectStats(context.Background(), target)
 
 	gathered, err := prometheus.DefaultGatherer.Gather()
 	if err != nil {
@@ -164,6 +174,30 @@ func TestCollectStatsForOrg(t *testing.T) {
 					t.Error("Unexpected value:", name, m.String())
 				}
 
+				if name == "github_repo_has_issues" && value != 1.0 {
+					t.Error("Unexpected value:", name, m.String())
+				}
+
+				if name == "github_repo_has_wiki" && value != 0.0 {
+					t.Error("Unexpected value:", name, m.String())
+				}
+
+				if name == "github_repo_pushed_timestamp_seconds" && value != 1529565575.0 {
+					t.Error("Unexpected value:", name, m.String())
+				}
+
+				if name == "github_repo_info" {
+					if !labelMatches(labels, "language", "Python") ||
+						!labelMatches(labels, "license", "Apache-2.0") ||
+						!labelMatches(labels, "visibility", "public") ||
+						!labelMatches(labels, "default_branch", "master") ||
+						!labelMatches(labels, "archived", "false") {
+						t.Error("Unexpected labels:", name, m.String())
+					}
+
+					tests["info"] = 1
+				}
+
 				tests["docker-py"] = 1
 			}
 
@@ -177,8 +211,217 @@ func TestCollectStatsForOrg(t *testing.T) {
 		}
 	}
 
-	if len(tests) != 3 {
-		t.Error("Only checked", len(tests), "metrics, but expected 3")
+	if len(tests) != 4 {
+		t.Error("Only checked", len(tests), "metrics, but expected 4")
+	}
+}
+
+func TestInfoIsReplacedWhenItChanges(t *testing.T) {
+	target := &Target{Name: "info-test", metrics: defaultMetrics}
+	defer deleteSeries(prometheus.Labels{"instance": "info-test"})
+
+	repository := &github.Repository{
+		Name:     github.String("podlike"),
+		Owner:    &github.User{Login: github.String("rycus86")},
+		Language: github.String("Go"),
+	}
+
+	updateInfo(target, repository)
+
+	repository.Language = github.String("Python")
+	updateInfo(target, repository)
+	updateInfo(target, repository)
+
+	expectGauge(t, "github_repo_info", prometheus.Labels{"instance": "info-test", "language": "Python"}, 1)
+
+	if _, found := gaugeValue(t, "github_repo_info", prometheus.Labels{"instance": "info-test", "language": "Go"}); found {
+		t.Error("The info with the previous labels should be removed")
+	}
+}
+
+func TestStaleRepositoriesAreRemoved(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	listing := `[
+		{"name": "podlike", "owner": {"login": "rycus86"}, "forks_count": 3},
+		{"name": "renamed", "owner": {"login": "rycus86"}, "forks_count": 1}
+	]`
+	failing := false
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			if failing {
+				return httpmock.NewStringResponse(500, `{"message": "Server Error"}`), nil
+			}
+
+			return httpmock.NewStringResponse(200, listing), nil
+		})
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	renamed := prometheus.Labels{"owner": "rycus86", "repository": "renamed"}
+	expectGauge(t, "github_forks_count", renamed, 1)
+
+	listing = `[{"name": "podlike", "owner": {"login": "rycus86"}, "forks_count": 3}]`
+	failing = true
+
+	collectStats(context.Background(), target)
+
+	if _, found := gaugeValue(t, "github_forks_count", renamed); !found {
+		t.Error("The metrics should be kept when the listing fails")
+	}
+
+	failing = false
+
+	collectStats(context.Background(), target)
+
+	if _, found := gaugeValue(t, "github_forks_count", renamed); found {
+		t.Error("The metrics of the repository no longer listed should be removed")
+	}
+
+	expectGauge(t, "github_forks_count", prometheus.Labels{"owner": "rycus86", "repository": "podlike"}, 3)
+}
+
+func TestStaleRepositoriesAreRemovedWithMixedCaseOwner(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	listing := `[
+		{"name": "podlike", "owner": {"login": "rycus86"}, "forks_count": 3},
+		{"name": "renamed", "owner": {"login": "rycus86"}, "forks_count": 1}
+	]`
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/Rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, listing), nil
+		})
+
+	// the login in the API responses differs in case from the configured name
+	target := &Target{Users: []Owner{{Name: "Rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	renamed := prometheus.Labels{"owner": "rycus86", "repository": "renamed"}
+	expectGauge(t, "github_forks_count", renamed, 1)
+
+	listing = `[{"name": "podlike", "owner": {"login": "rycus86"}, "forks_count": 3}]`
+
+	collectStats(context.Background(), target)
+
+	if _, found := gaugeValue(t, "github_forks_count", renamed); found {
+		t.Error("The metrics of the repository no longer listed should be removed")
+	}
+
+	if repositories, found := target.seenRepositories("Rycus86"); !found || !repositories["podlike"] {
+		t.Error("Unexpected repositories seen:", repositories, found)
+	}
+}
+
+func TestStaleGracePeriod(t *testing.T) {
+	target := &Target{StaleGracePeriod: Duration{time.Hour}}
+	now := time.Now()
+
+	target.markSeen("rycus86", map[string]bool{"podlike": true, "renamed": true}, now)
+
+	if stale := target.markSeen("rycus86", map[string]bool{"podlike": true}, now.Add(30*time.Minute)); len(stale) != 0 {
+		t.Error("Unexpected stale repositories within the grace period:", stale)
+	}
+
+	if repositories, _ := target.seenRepositories("rycus86"); !repositories["renamed"] {
+		t.Error("The repository should still be known within the grace period")
+	}
+
+	if stale := target.markSeen("rycus86", map[string]bool{"podlike": true}, now.Add(90*time.Minute)); strings.Join(stale, ",") != "renamed" {
+		t.Error("Unexpected stale repositories after the grace period:", stale)
+	}
+
+	if repositories, _ := target.seenRepositories("rycus86"); repositories["renamed"] || !repositories["podlike"] {
+		t.Error("Unexpected known repositories:", repositories)
+	}
+}
+
+func TestConcurrentCollection(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	var orgs []Owner
+
+	for idx := 0; idx < 5; idx++ {
+		org := fmt.Sprintf("org-%d", idx)
+		orgs = append(orgs, Owner{Name: org})
+
+		var repos []string
+		for repo := 0; repo < 4; repo++ {
+			repos = append(repos, fmt.Sprintf(`{"name": "repo-%d", "full_name": "%s/repo-%d", "owner": {"login": "%s"}, "forks_count": %d}`,
+				repo, org, repo, org, repo))
+
+			httpmock.RegisterResponder("GET", fmt.Sprintf("https://api.github.com/repos/%s/repo-%d/languages", org, repo),
+				httpmock.NewStringResponder(200, `{"Go": 100}`))
+		}
+
+		httpmock.RegisterResponder("GET", "https://api.github.com/orgs/"+org+"/repos",
+			httpmock.NewStringResponder(200, "["+strings.Join(repos, ",")+"]"))
+	}
+
+	defer func() {
+		for _, org := range orgs {
+			deleteSeries(prometheus.Labels{"owner": org.Name})
+		}
+	}()
+
+	target := &Target{Orgs: orgs, Collect: []string{"languages"}, Concurrency: 3, RepositoryConcurrency: 4}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	for _, org := range orgs {
+		expectGauge(t, "github_repo_count", prometheus.Labels{"owner": org.Name}, 4)
+		expectGauge(t, "github_forks_count", prometheus.Labels{"owner": org.Name, "repository": "repo-3"}, 3)
+		expectGauge(t, "github_owner_language_bytes", prometheus.Labels{"owner": org.Name, "language": "Go"}, 400)
+	}
+}
+
+func TestCollectionIsCancelledWhenItOverruns(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			time.Sleep(50 * time.Millisecond)
+
+			resp := httpmock.NewStringResponse(200, testRepositories)
+			resp.Header.Set("Link", "<https://api.github.com/users/rycus86/repos?page=2>; rel=\"next\"")
+			return resp, nil
+		})
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Interval: Duration{20 * time.Millisecond}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+	target.collect(context.Background())
+
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Error("The collection should have been cancelled:", elapsed)
+	}
+
+	if _, found := target.seenRepositories("rycus86"); found {
+		t.Error("The cancelled listing should not count as a successful one")
 	}
 }
 
@@ -193,7 +436,10 @@ func labelMatches(labels []*dto.LabelPair, name, value string) bool {
 }
 
 func TestUnauthenticatedClient(t *testing.T) {
-	client := getApiClient()
+	client, err := flagTarget().httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if _, ok := client.Transport.(*httpcache.Transport); !ok {
 		t.Errorf("Unexpected API client transport: %T\n", client.Transport)
@@ -203,8 +449,15 @@ func TestUnauthenticatedClient(t *testing.T) {
 func TestAuthenticatedClientByUsernameAndPassword(t *testing.T) {
 	*usernameVar = "example"
 	*passwordVar = "p4$$w0rd"
+	defer func() {
+		*usernameVar = ""
+		*passwordVar = ""
+	}()
 
-	client := getApiClient()
+	client, err := flagTarget().httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if tp, ok := client.Transport.(*github.BasicAuthTransport); !ok {
 		t.Errorf("Unexpected API client transport: %T\n", client.Transport)
@@ -223,9 +476,13 @@ func TestAuthenticatedClientByCredentials(t *testing.T) {
 		tf.Close()
 
 		*credentialsFile = tf.Name()
+		defer func() { *credentialsFile = "" }()
 	}
 
-	client := getApiClient()
+	client, err := flagTarget().httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if tp, ok := client.Transport.(*github.BasicAuthTransport); !ok {
 		t.Errorf("Unexpected API client transport: %T\n", client.Transport)
@@ -233,3 +490,47 @@ func TestAuthenticatedClientByCredentials(t *testing.T) {
 		t.Errorf("Invalid username/password found: %s:%s", tp.Username, tp.Password)
 	}
 }
+
+func TestEnterpriseClientWithCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/users/rycus86" {
+			t.Error("Unexpected request:", r.URL.Path)
+		}
+
+		w.Write([]byte(`{"login": "rycus86"}`))
+	}))
+	defer server.Close()
+
+	tf, err := ioutil.TempFile("", "gh-exporter-ca")
+	if err != nil {
+		t.Fatal("Failed to create a temporary file:", err)
+	}
+	defer os.Remove(tf.Name())
+
+	pem.Encode(tf, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	tf.Close()
+
+	*apiURL = server.URL + "/api/v3"
+	*caFile = tf.Name()
+	defer func() {
+		*apiURL = ""
+		*caFile = ""
+	}()
+
+	target := flagTarget()
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	client := target.client
+
+	if client.UploadURL.String() != server.URL+"/api/v3/" {
+		t.Error("Unexpected upload URL:", client.UploadURL)
+	}
+
+	if user, _, err := client.Users.Get(context.Background(), "rycus86"); err != nil {
+		t.Fatal(err)
+	} else if user.GetLogin() != "rycus86" {
+		t.Error("Unexpected user:", user.GetLogin())
+	}
+}
@@ -6,18 +6,47 @@ import (
 )
 
 var (
+	configFile = flag.String("config", "", "Configuration file `path` in JSON format (optional, flags take precedence)")
+
 	port      = flag.Int("port", 8080, "The HTTP port to listen on")
 	interval  = flag.Duration("interval", 15*time.Minute, "Interval between checks")
 	timeout   = flag.Duration("timeout", 15*time.Second, "HTTP API call timeout")
 	skipForks = flag.Bool("skip-forks", false, "Do not pull metrics for forked repositories")
 
-	users multiVar
-	orgs  multiVar
+	concurrency           = flag.Int("concurrency", 1, "Number of users and organizations to collect metrics for at the same time, per target")
+	repositoryConcurrency = flag.Int("repository-concurrency", 1, "Number of repositories to run the additional collectors for at the same time, per target")
+
+	staleGracePeriod = flag.Duration("stale-grace-period", 0,
+		"How long to keep the metrics of repositories that are no longer listed, for example after they were deleted or renamed")
+
+	onDemandMode = flag.Bool("on-demand", false, "Collect the metrics when they are scraped, instead of periodically")
+	snapshotMode = flag.Bool("snapshot", false, "Serve the metrics of the last complete collection of each target, instead of the ones being updated")
+
+	topContributors = flag.Int("top-contributors", 10, "Number of top contributors to export commit counts for, per repository")
+
+	apiURL             = flag.String("api-url", "", "Base `URL` of the v3 API, for GitHub Enterprise (optional)")
+	uploadURL          = flag.String("upload-url", "", "Upload `URL` of the v3 API, for GitHub Enterprise (optional, defaults to -api-url)")
+	caFile             = flag.String("ca-file", "", "File `path` containing additional trusted CA certificates in PEM format (optional)")
+	insecureSkipVerify = flag.Bool("insecure-skip-verify", false, "Do not verify the TLS certificate of the API server")
+
+	users       multiVar
+	orgs        multiVar
+	collect     multiVar
+	issueLabels multiVar
+
+	targetsFile = flag.String("targets", "", "File `path` containing additional targets to collect metrics from in JSON format (optional)")
 
 	usernameVar     = flag.String("username", "", "Username for authenticated API calls (optional)")
 	passwordVar     = flag.String("password", "", "Password for authenticated API calls (optional)")
 	credentialsFile = flag.String("credentials", "",
 		"File `path` containing the authentication details in `username:password` format (optional)")
+
+	tokenVar  = flag.String("token", "", "Personal access token or OAuth token for authenticated API calls (optional)")
+	tokenFile = flag.String("token-file", "", "File `path` containing the access token, read again when it changes (optional)")
+
+	appID             = flag.Int64("app-id", 0, "GitHub App ID to authenticate as (optional)")
+	appInstallationID = flag.Int64("app-installation-id", 0, "Installation ID of the GitHub App (required with -app-id)")
+	appKeyFile        = flag.String("app-key", "", "File `path` containing the private key of the GitHub App in PEM format (required with -app-id)")
 )
 
 type multiVar []string
@@ -43,6 +72,6 @@ func (mv *multiVar) String() string {
 func init() {
 	flag.Var(&users, "user", "Users to list repositories for (multiple values are allowed)")
 	flag.Var(&orgs, "org", "Organizations to list repositories for (multiple values are allowed)")
-
-	flag.Parse()
+	flag.Var(&collect, "collect", "Additional collectors to enable, which need further API calls (multiple values are allowed)")
+	flag.Var(&issueLabels, "issue-label", "Issue labels to count open issues by, all of them if not set (multiple values are allowed)")
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+var (
+	issuesOpen = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "issues_open",
+		Help:      "Number of Open Issues, excluding Pull Requests",
+	}, []string{"instance", "owner", "repository"})
+	issuesOpenByLabel = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "issues_open_by_label",
+		Help:      "Number of Open Issues by Label",
+	}, []string{"instance", "owner", "repository", "label"})
+	issuesOpenByMilestone = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "issues_open_by_milestone",
+		Help:      "Number of Open Issues by Milestone",
+	}, []string{"instance", "owner", "repository", "milestone"})
+	issuesUnassigned = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "issues_unassigned",
+		Help:      "Number of Open Issues without Assignees",
+	}, []string{"instance", "owner", "repository"})
+	issuesOldestAge = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "issues_oldest_open_age_seconds",
+		Help:      "Age of the Oldest Open Issue in seconds",
+	}, []string{"instance", "owner", "repository"})
+)
+
+func collectIssues(ctx context.Context, target *Target, repository *github.Repository) error {
+	var (
+		open        = 0
+		byLabel     = map[string]int{}
+		byMilestone = map[string]int{}
+		unassigned  = 0
+		oldest      time.Time
+	)
+
+	allowedLabels := map[string]bool{}
+	for _, label := range target.IssueLabels {
+		allowedLabels[label] = true
+	}
+
+	opts := &github.IssueListByRepoOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		issues, resp, err := target.client.Issues.ListByRepo(
+			ctx, repository.GetOwner().GetLogin(), repository.GetName(), opts)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range issues {
+			// the issues API returns pull requests as well
+			if issue.IsPullRequest() {
+				continue
+			}
+
+			open++
+
+			for _, label := range issue.Labels {
+				if len(allowedLabels) == 0 || allowedLabels[label.GetName()] {
+					byLabel[label.GetName()]++
+				}
+			}
+
+			if issue.Milestone != nil {
+				byMilestone[issue.Milestone.GetTitle()]++
+			}
+
+			if len(issue.Assignees) == 0 && issue.Assignee == nil {
+				unassigned++
+			}
+
+			if oldest.IsZero() || issue.GetCreatedAt().Before(oldest) {
+				oldest = issue.GetCreatedAt()
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	labels := repositoryLabels(target, repository)
+
+	openByLabel := target.updateSeries(issuesOpenByLabel, repositorySeries(target, repository))
+	openByMilestone := target.updateSeries(issuesOpenByMilestone, repositorySeries(target, repository))
+
+	for label, count := range byLabel {
+		openByLabel.WithLabelValues(append(labels, label)...).Set(float64(count))
+	}
+
+	for milestone, count := range byMilestone {
+		openByMilestone.WithLabelValues(append(labels, milestone)...).Set(float64(count))
+	}
+
+	openByLabel.done()
+	openByMilestone.done()
+
+	target.gauge(issuesOpen).WithLabelValues(labels...).Set(float64(open))
+	target.gauge(issuesUnassigned).WithLabelValues(labels...).Set(float64(unassigned))
+
+	if oldest.IsZero() {
+		target.gauge(issuesOldestAge).DeleteLabelValues(labels...)
+	} else {
+		target.gauge(issuesOldestAge).WithLabelValues(labels...).Set(time.Since(oldest).Seconds())
+	}
+
+	return nil
+}
+
+func init() {
+	register(issuesOpen)
+	register(issuesOpenByLabel)
+	register(issuesOpenByMilestone)
+	register(issuesUnassigned)
+	register(issuesOldestAge)
+
+	addRepositoryCollector(RepositoryCollector{Name: "issues", Collect: collectIssues})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+func TestCollectIssues(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/issues",
+		httpmock.NewStringResponder(200, `[
+			{"number": 9, "created_at": "2018-07-01T10:00:00Z", "labels": [{"name": "bug"}, {"name": "needs-triage"}],
+			 "milestone": {"title": "0.4.0"}},
+			{"number": 8, "created_at": "2018-06-01T10:00:00Z", "labels": [{"name": "bug"}],
+			 "assignee": {"login": "rycus86"}, "assignees": [{"login": "rycus86"}]},
+			{"number": 7, "created_at": "2018-05-01T10:00:00Z", "labels": [{"name": "bug"}],
+			 "pull_request": {"url": "https://api.github.com/repos/rycus86/podlike/pulls/7"}}
+		]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/issues",
+		httpmock.NewStringResponder(200, `[]`))
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		httpmock.NewStringResponder(200, testRepositories))
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Collect: []string{"issues"}, IssueLabels: []string{"bug"}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	labels := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_issues_open", labels, 2)
+	expectGauge(t, "github_issues_unassigned", labels, 1)
+	expectGauge(t, "github_issues_open_by_label", prometheus.Labels{"repository": "podlike", "label": "bug"}, 2)
+	expectGauge(t, "github_issues_open_by_milestone", prometheus.Labels{"repository": "podlike", "milestone": "0.4.0"}, 1)
+
+	if _, found := gaugeValue(t, "github_issues_open_by_label", prometheus.Labels{"label": "needs-triage"}); found {
+		t.Error("Unexpected metric for a label not on the allowlist")
+	}
+
+	if _, found := gaugeValue(t, "github_issues_oldest_open_age_seconds", labels); !found {
+		t.Error("The age of the oldest issue was not found")
+	}
+
+	expectGauge(t, "github_issues_open", prometheus.Labels{"repository": "forked"}, 0)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+var (
+	repoLanguageBytes = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "repo_language_bytes",
+		Help:      "Number of Bytes of code in the Repository by Language",
+	}, []string{"instance", "owner", "repository", "language"})
+	ownerLanguageBytes = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "owner_language_bytes",
+		Help:      "Number of Bytes of code in the Repositories of the Owner by Language",
+	}, []string{"instance", "owner", "language"})
+)
+
+type repositoryLanguages struct {
+	PushedAt  time.Time
+	Languages map[string]int
+}
+
+// collectLanguages collects the number of bytes of code by language.
+// The languages only change with a push, so they are requested again
+// only if the repository was pushed to since the last collection.
+func collectLanguages(ctx context.Context, target *Target, repository *github.Repository) error {
+	owner, name := repository.GetOwner().GetLogin(), repository.GetName()
+	key := target.Name + "/" + owner
+
+	cache := target.metrics
+
+	cache.languagesLock.Lock()
+	cached, found := cache.languages[key][name]
+	cache.languagesLock.Unlock()
+
+	if !found || !cached.PushedAt.Equal(repository.GetPushedAt().Time) {
+		languages, _, err := target.client.Repositories.ListLanguages(ctx, owner, name)
+		if err != nil {
+			return err
+		}
+
+		cached = repositoryLanguages{PushedAt: repository.GetPushedAt().Time, Languages: languages}
+	}
+
+	cache.languagesLock.Lock()
+	defer cache.languagesLock.Unlock()
+
+	if cache.languages[key] == nil {
+		cache.languages[key] = map[string]repositoryLanguages{}
+	}
+
+	cache.languages[key][name] = cached
+
+	repositoryBytes := target.updateSeries(repoLanguageBytes, repositorySeries(target, repository))
+
+	for language, bytes := range cached.Languages {
+		repositoryBytes.WithLabelValues(append(repositoryLabels(target, repository), language)...).Set(float64(bytes))
+	}
+
+	repositoryBytes.done()
+
+	totals := map[string]int{}
+
+	for _, repositoryLanguages := range cache.languages[key] {
+		for language, bytes := range repositoryLanguages.Languages {
+			totals[language] += bytes
+		}
+	}
+
+	ownerBytes := target.updateSeries(ownerLanguageBytes, prometheus.Labels{"instance": target.Name, "owner": owner})
+
+	for language, bytes := range totals {
+		ownerBytes.WithLabelValues(target.Name, owner, language).Set(float64(bytes))
+	}
+
+	ownerBytes.done()
+
+	return nil
+}
+
+func forgetLanguages(target *Target, owner, repository string) {
+	target.metrics.languagesLock.Lock()
+	defer target.metrics.languagesLock.Unlock()
+
+	delete(target.metrics.languages[target.Name+"/"+owner], repository)
+}
+
+func init() {
+	register(repoLanguageBytes)
+	register(ownerLanguageBytes)
+
+	addRepositoryCollector(RepositoryCollector{Name: "languages", Collect: collectLanguages, Forget: forgetLanguages})
+}
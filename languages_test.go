@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"net/http"
+	"testing"
+)
+
+func TestCollectLanguages(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	pushedAt := "2018-06-21T07:19:35Z"
+	requests := 0
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, fmt.Sprintf(`[
+				{"name": "api", "owner": {"login": "rycus86"}, "pushed_at": "%[1]s"},
+				{"name": "web", "owner": {"login": "rycus86"}, "pushed_at": "%[1]s"}
+			]`, pushedAt)), nil
+		})
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/api/languages",
+		func(req *http.Request) (*http.Response, error) {
+			requests++
+			return httpmock.NewStringResponse(200, `{"Go": 12000, "Shell": 300}`), nil
+		})
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/web/languages",
+		func(req *http.Request) (*http.Response, error) {
+			requests++
+			return httpmock.NewStringResponse(200, `{"JavaScript": 5000, "Shell": 200}`), nil
+		})
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Collect: []string{"languages"}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	expectGauge(t, "github_repo_language_bytes", prometheus.Labels{"repository": "api", "language": "Go"}, 12000)
+	expectGauge(t, "github_repo_language_bytes", prometheus.Labels{"repository": "web", "language": "Shell"}, 200)
+	expectGauge(t, "github_owner_language_bytes", prometheus.Labels{"owner": "rycus86", "language": "Shell"}, 500)
+	expectGauge(t, "github_owner_language_bytes", prometheus.Labels{"owner": "rycus86", "language": "Go"}, 12000)
+
+	if requests != 2 {
+		t.Error("Unexpected number of requests:", requests)
+	}
+
+	collectStats(context.Background(), target)
+
+	if requests != 2 {
+		t.Error("The languages should not be requested again without a push:", requests)
+	}
+
+	pushedAt = "2018-06-22T10:00:00Z"
+
+	collectStats(context.Background(), target)
+
+	if requests != 4 {
+		t.Error("The languages should be requested again after a push:", requests)
+	}
+}
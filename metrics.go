@@ -3,81 +3,241 @@ package main
 import (
 	"github.com/google/go-github/github"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"strconv"
+	"strings"
 )
 
 var (
 	metrics []Metric
 
-	repoCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	// every metric vector registered, to be able to delete series from them
+	metricVecs []metricVec
+
+	repoCount = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "github",
 		Name:      "repo_count",
 		Help:      "Number of Repositories",
-	}, []string{"owner"})
+	}, []string{"instance", "owner"})
+
+	repoInfo = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "repo_info",
+		Help:      "Information about the Repository, the value is always 1",
+	}, []string{
+		"instance", "owner", "repository",
+		"language", "license", "topics", "visibility", "default_branch",
+		"archived", "fork", "has_issues", "has_wiki",
+	})
 
-	rateLimit = prometheus.NewGauge(prometheus.GaugeOpts{
+	rateLimit = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "github",
 		Name:      "rate_limit",
 		Help:      "API Rate Limit",
-	})
-	rateRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, []string{"instance"})
+	rateRemaining = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "github",
 		Name:      "rate_remaining",
 		Help:      "API Rate Remaining",
-	})
-	rateReset = prometheus.NewGauge(prometheus.GaugeOpts{
+	}, []string{"instance"})
+	rateReset = newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "github",
 		Name:      "rate_reset",
 		Help:      "API Rate Reset",
-	})
+	}, []string{"instance"})
 )
 
 type Metric struct {
 	Name      string
 	Help      string
-	Extractor func(repository *github.Repository) *int
+	Extractor func(repository *github.Repository) *float64
 
 	gauge *prometheus.GaugeVec
 }
 
-func (m *Metric) Update(repository *github.Repository) {
+func (m *Metric) Update(target *Target, repository *github.Repository) {
 	if value := m.Extractor(repository); value != nil {
-		m.gauge.WithLabelValues(
-			repository.GetOwner().GetLogin(), repository.GetName(),
-		).Set(float64(*value))
+		target.gauge(m.gauge).WithLabelValues(repositoryLabels(target, repository)...).Set(*value)
+	}
+}
+
+// updateInfo sets the info metric of the repository,
+// replacing the previous one if any of its labels have changed.
+func updateInfo(target *Target, repository *github.Repository) {
+	visibility := "public"
+	if repository.GetPrivate() {
+		visibility = "private"
+	}
+
+	info := target.updateSeries(repoInfo, repositorySeries(target, repository))
+	defer info.done()
+
+	info.WithLabelValues(append(repositoryLabels(target, repository),
+		repository.GetLanguage(),
+		repository.GetLicense().GetSPDXID(),
+		strings.Join(repository.Topics, ","),
+		visibility,
+		repository.GetDefaultBranch(),
+		strconv.FormatBool(repository.GetArchived()),
+		strconv.FormatBool(repository.GetFork()),
+		strconv.FormatBool(repository.GetHasIssues()),
+		strconv.FormatBool(repository.GetHasWiki()),
+	)...).Set(1)
+}
+
+// countOf converts the number into a gauge value, for the extractors.
+func countOf(number *int) *float64 {
+	if number == nil {
+		return nil
 	}
+
+	value := float64(*number)
+	return &value
+}
+
+// flagOf converts the flag into a 1 or 0 gauge value, for the extractors.
+func flagOf(flag *bool) *float64 {
+	if flag == nil {
+		return nil
+	}
+
+	value := boolValue(*flag)
+	return &value
+}
+
+// timestampOf converts the time into unix seconds, for the extractors.
+func timestampOf(t *github.Timestamp) *float64 {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+
+	value := float64(t.Unix())
+	return &value
 }
 
 func addMetric(metric Metric) {
-	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	gauge := newGaugeVec(prometheus.GaugeOpts{
 		Namespace: "github",
 		Name:      metric.Name,
 		Help:      metric.Help,
-	}, []string{"owner", "repository"})
+	}, []string{"instance", "owner", "repository"})
 
-	prometheus.MustRegister(gauge)
+	register(gauge)
 
 	metric.gauge = gauge
 	metrics = append(metrics, metric)
 }
 
+// metricVec is a gauge or histogram vector, that series can be deleted from.
+type metricVec interface {
+	prometheus.Collector
+
+	Delete(labels prometheus.Labels) bool
+}
+
+func register(vec metricVec) {
+	prometheus.MustRegister(vec)
+
+	metricVecs = append(metricVecs, vec)
+}
+
+// deleteSeriesFrom removes every series from the metric vector
+// that has all the given labels with the given values.
+func deleteSeriesFrom(vec metricVec, labels prometheus.Labels) {
+	var matching []prometheus.Labels
+
+	for _, s := range seriesOf(vec) {
+		if hasLabels(s.Labels, labels) {
+			matching = append(matching, s.Labels)
+		}
+	}
+
+	for _, seriesLabels := range matching {
+		vec.Delete(seriesLabels)
+	}
+}
+
+// series is the current state of a metric in a vector, with its labels.
+type series struct {
+	Desc   *prometheus.Desc
+	Metric *dto.Metric
+	Labels prometheus.Labels
+}
+
+// seriesOf returns the current state of every series of the metric vector.
+func seriesOf(vec metricVec) []series {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+
+	var all []series
+
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			continue
+		}
+
+		seriesLabels := prometheus.Labels{}
+		for _, pair := range pb.GetLabel() {
+			seriesLabels[pair.GetName()] = pair.GetValue()
+		}
+
+		all = append(all, series{Desc: m.Desc(), Metric: pb, Labels: seriesLabels})
+	}
+
+	return all
+}
+
+func hasLabels(labels, expected prometheus.Labels) bool {
+	for name, value := range expected {
+		if actual, ok := labels[name]; !ok || actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
 func init() {
-	prometheus.MustRegister(repoCount)
-	prometheus.MustRegister(rateLimit)
-	prometheus.MustRegister(rateRemaining)
-	prometheus.MustRegister(rateReset)
+	register(repoCount)
+	register(repoInfo)
+	register(rateLimit)
+	register(rateRemaining)
+	register(rateReset)
 
 	addMetric(Metric{Name: "forks_count", Help: "Number of Forks",
-		Extractor: func(r *github.Repository) *int { return r.ForksCount }})
+		Extractor: func(r *github.Repository) *float64 { return countOf(r.ForksCount) }})
 	addMetric(Metric{Name: "networks_count", Help: "Number of Networks",
-		Extractor: func(r *github.Repository) *int { return r.NetworkCount }})
+		Extractor: func(r *github.Repository) *float64 { return countOf(r.NetworkCount) }})
 	addMetric(Metric{Name: "open_issues_count", Help: "Number of Open Issues",
-		Extractor: func(r *github.Repository) *int { return r.OpenIssuesCount }})
+		Extractor: func(r *github.Repository) *float64 { return countOf(r.OpenIssuesCount) }})
 	addMetric(Metric{Name: "stargazers_count", Help: "Number of Stars",
-		Extractor: func(r *github.Repository) *int { return r.StargazersCount }})
+		Extractor: func(r *github.Repository) *float64 { return countOf(r.StargazersCount) }})
 	addMetric(Metric{Name: "subscribers_count", Help: "Number of Subscribers",
-		Extractor: func(r *github.Repository) *int { return r.SubscribersCount }})
+		Extractor: func(r *github.Repository) *float64 { return countOf(r.SubscribersCount) }})
 	addMetric(Metric{Name: "watchers_count", Help: "Number of Watchers",
-		Extractor: func(r *github.Repository) *int { return r.WatchersCount }})
+		Extractor: func(r *github.Repository) *float64 { return countOf(r.WatchersCount) }})
 	addMetric(Metric{Name: "size_kilobytes", Help: "Size of the Repository in kiloBytes",
-		Extractor: func(r *github.Repository) *int { return r.Size }})
+		Extractor: func(r *github.Repository) *float64 { return countOf(r.Size) }})
+
+	addMetric(Metric{Name: "repo_archived", Help: "Whether the Repository is Archived (1) or not (0)",
+		Extractor: func(r *github.Repository) *float64 { return flagOf(r.Archived) }})
+	addMetric(Metric{Name: "repo_private", Help: "Whether the Repository is Private (1) or not (0)",
+		Extractor: func(r *github.Repository) *float64 { return flagOf(r.Private) }})
+	addMetric(Metric{Name: "repo_fork", Help: "Whether the Repository is a Fork (1) or not (0)",
+		Extractor: func(r *github.Repository) *float64 { return flagOf(r.Fork) }})
+	addMetric(Metric{Name: "repo_has_issues", Help: "Whether the Repository has Issues enabled (1) or not (0)",
+		Extractor: func(r *github.Repository) *float64 { return flagOf(r.HasIssues) }})
+	addMetric(Metric{Name: "repo_has_wiki", Help: "Whether the Repository has the Wiki enabled (1) or not (0)",
+		Extractor: func(r *github.Repository) *float64 { return flagOf(r.HasWiki) }})
+
+	addMetric(Metric{Name: "repo_created_timestamp_seconds", Help: "Time when the Repository was Created, in unix seconds",
+		Extractor: func(r *github.Repository) *float64 { return timestampOf(r.CreatedAt) }})
+	addMetric(Metric{Name: "repo_pushed_timestamp_seconds", Help: "Time of the last Push to the Repository, in unix seconds",
+		Extractor: func(r *github.Repository) *float64 { return timestampOf(r.PushedAt) }})
+	addMetric(Metric{Name: "repo_updated_timestamp_seconds", Help: "Time when the Repository was last Updated, in unix seconds",
+		Extractor: func(r *github.Repository) *float64 { return timestampOf(r.UpdatedAt) }})
 }
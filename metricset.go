@@ -0,0 +1,194 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// the metric set of the configured targets, using the registered metric vectors
+	defaultMetrics = newMetricSet()
+
+	// the functions that create a new metric vector like a registered one, for the probes
+	vecFactories = map[metricVec]func() metricVec{}
+)
+
+// metricSet holds the metric vectors the collections write to, and the
+// state the collectors keep between them. The configured targets share
+// the default set, and each probe collects into a new one, so that its
+// results are kept apart from the metrics on /metrics.
+type metricSet struct {
+	// the vectors of the set by the registered ones, nil for the default set
+	vecs map[metricVec]metricVec
+
+	// the label values of the series last set by each update, by metric vector
+	updated     map[metricVec]map[string]*updatedLabels
+	updatedLock sync.Mutex
+
+	// the languages of the repositories, with the time of the push they are from
+	languages     map[string]map[string]repositoryLanguages
+	languagesLock sync.Mutex
+
+	// the stargazers already listed, by repository
+	stars     map[string]*starHistory
+	starsLock sync.Mutex
+
+	// the time of the latest merged pull request already observed, by repository
+	merged     map[string]time.Time
+	mergedLock sync.Mutex
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{
+		updated:   map[metricVec]map[string]*updatedLabels{},
+		languages: map[string]map[string]repositoryLanguages{},
+		stars:     map[string]*starHistory{},
+		merged:    map[string]time.Time{},
+	}
+}
+
+// newRegisteredMetricSet returns a metric set with new metric vectors,
+// registered in the given registry instead of the default one.
+func newRegisteredMetricSet(registry prometheus.Registerer) *metricSet {
+	set := newMetricSet()
+	set.vecs = map[metricVec]metricVec{}
+
+	for _, vec := range metricVecs {
+		created := vecFactories[vec]()
+		registry.MustRegister(created)
+
+		set.vecs[vec] = created
+	}
+
+	return set
+}
+
+func newGaugeVec(opts prometheus.GaugeOpts, labels []string) *prometheus.GaugeVec {
+	vec := prometheus.NewGaugeVec(opts, labels)
+	vecFactories[vec] = func() metricVec {
+		return prometheus.NewGaugeVec(opts, labels)
+	}
+
+	return vec
+}
+
+func newHistogramVec(opts prometheus.HistogramOpts, labels []string) *prometheus.HistogramVec {
+	vec := prometheus.NewHistogramVec(opts, labels)
+	vecFactories[vec] = func() metricVec {
+		return prometheus.NewHistogramVec(opts, labels)
+	}
+
+	return vec
+}
+
+// vec returns the metric vector of the set for the registered one.
+func (s *metricSet) vec(vec metricVec) metricVec {
+	if s.vecs == nil {
+		return vec
+	}
+
+	return s.vecs[vec]
+}
+
+// gauge returns the gauge vector the target collects into for the registered one.
+func (t *Target) gauge(vec *prometheus.GaugeVec) *prometheus.GaugeVec {
+	return t.metrics.vec(vec).(*prometheus.GaugeVec)
+}
+
+// histogram returns the histogram vector the target collects into for the registered one.
+func (t *Target) histogram(vec *prometheus.HistogramVec) *prometheus.HistogramVec {
+	return t.metrics.vec(vec).(*prometheus.HistogramVec)
+}
+
+// deleteSeries removes every series from the registered metric vectors
+// that has all the given labels with the given values.
+func deleteSeries(labels prometheus.Labels) {
+	defaultMetrics.deleteSeries(labels)
+}
+
+// deleteSeries removes every series from the metric vectors of the set
+// that has all the given labels with the given values.
+func (s *metricSet) deleteSeries(labels prometheus.Labels) {
+	for _, vec := range metricVecs {
+		deleteSeriesFrom(s.vec(vec), labels)
+	}
+
+	s.updatedLock.Lock()
+	defer s.updatedLock.Unlock()
+
+	for _, updates := range s.updated {
+		for key, updated := range updates {
+			if hasLabels(updated.labels, labels) {
+				delete(updates, key)
+			}
+		}
+	}
+}
+
+// seriesUpdate sets a group of series of a gauge vector, like the ones of
+// a repository with labels that come and go between the collections.
+// When it is done, the series of the group that were set by the previous
+// update but not by this one are deleted, without scanning the vector.
+type seriesUpdate struct {
+	set     *metricSet
+	vec     *prometheus.GaugeVec
+	labels  prometheus.Labels
+	current map[string][]string
+}
+
+type updatedLabels struct {
+	labels prometheus.Labels
+	values map[string][]string
+}
+
+// updateSeries starts an update of the series of the vector
+// that have all the given labels with the given values.
+func (t *Target) updateSeries(vec *prometheus.GaugeVec, labels prometheus.Labels) *seriesUpdate {
+	return &seriesUpdate{set: t.metrics, vec: t.gauge(vec), labels: labels, current: map[string][]string{}}
+}
+
+func (u *seriesUpdate) WithLabelValues(values ...string) prometheus.Gauge {
+	// a copy, as the callers append the values to shared slices
+	u.current[strings.Join(values, "\xff")] = append([]string(nil), values...)
+
+	return u.vec.WithLabelValues(values...)
+}
+
+func (u *seriesUpdate) done() {
+	key := labelsKey(u.labels)
+
+	u.set.updatedLock.Lock()
+	defer u.set.updatedLock.Unlock()
+
+	updates := u.set.updated[u.vec]
+	if updates == nil {
+		updates = map[string]*updatedLabels{}
+		u.set.updated[u.vec] = updates
+	}
+
+	if previous, found := updates[key]; found {
+		for id, values := range previous.values {
+			if _, set := u.current[id]; !set {
+				u.vec.DeleteLabelValues(values...)
+			}
+		}
+	}
+
+	updates[key] = &updatedLabels{labels: u.labels, values: u.current}
+}
+
+// labelsKey returns the label pairs as a string, sorted by their names.
+func labelsKey(labels prometheus.Labels) string {
+	var pairs []string
+
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "\xff")
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// the scrape timeout to use when Prometheus does not send one
+	defaultScrapeTimeout = 10 * time.Second
+	// the time left from the scrape timeout to send the response
+	scrapeTimeoutOffset = 500 * time.Millisecond
+)
+
+// scrapeCollection is a collection triggered by a scrape,
+// shared by the scrapes arriving while it is in progress.
+type scrapeCollection struct {
+	done chan struct{}
+}
+
+var (
+	currentCollection *scrapeCollection
+	collectionLock    sync.Mutex
+)
+
+// onDemandHandler collects the metrics of every target before serving
+// the scrape with the next handler.
+func onDemandHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		collection := startCollection(scrapeTimeout(r))
+
+		select {
+		case <-collection.done:
+			next.ServeHTTP(w, r)
+
+		case <-r.Context().Done():
+			// the scraper gave up already
+		}
+	})
+}
+
+// scrapeTimeout returns the time the collection can take,
+// based on the timeout Prometheus sends with the scrape.
+func scrapeTimeout(r *http.Request) time.Duration {
+	timeout := defaultScrapeTimeout
+
+	if header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); header != "" {
+		if seconds, err := strconv.ParseFloat(header, 64); err == nil && seconds > 0 {
+			timeout = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	if timeout > 2*scrapeTimeoutOffset {
+		timeout -= scrapeTimeoutOffset
+	}
+
+	return timeout
+}
+
+// waitForCollection waits until no collection triggered by a scrape is in
+// progress, and returns with the collectionLock held, so that no other one
+// starts until it is unlocked.
+func waitForCollection() {
+	for {
+		collectionLock.Lock()
+
+		collection := currentCollection
+		if collection == nil {
+			return
+		}
+
+		collectionLock.Unlock()
+
+		<-collection.done
+	}
+}
+
+// startCollection starts collecting the metrics of every target,
+// unless a collection is already in progress, and returns it.
+func startCollection(timeout time.Duration) *scrapeCollection {
+	collectionLock.Lock()
+	defer collectionLock.Unlock()
+
+	if currentCollection != nil {
+		return currentCollection
+	}
+
+	collection := &scrapeCollection{done: make(chan struct{})}
+	currentCollection = collection
+
+	targetsLock.Lock()
+	targets := activeTargets
+	targetsLock.Unlock()
+
+	go func() {
+		// not the context of the request, as other scrapes may be waiting for the results
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var wg sync.WaitGroup
+
+		for _, target := range targets {
+			wg.Add(1)
+
+			go func(target *Target) {
+				defer wg.Done()
+
+				collectStats(ctx, target)
+
+				// like the periodic collections, keep the previous snapshot if this one is incomplete
+				if ctx.Err() != nil {
+					log.Println("The collection from", target.Name, "did not complete:", ctx.Err())
+					return
+				}
+
+				updateSnapshot(target)
+			}(target)
+		}
+
+		wg.Wait()
+
+		collectionLock.Lock()
+		currentCollection = nil
+		collectionLock.Unlock()
+
+		close(collection.done)
+	}()
+
+	return collection
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"gopkg.in/jarcoal/httpmock.v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScrapeTimeout(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":        defaultScrapeTimeout - scrapeTimeoutOffset,
+		"5":       4500 * time.Millisecond,
+		"0.5":     500 * time.Millisecond,
+		"invalid": defaultScrapeTimeout - scrapeTimeoutOffset,
+	}
+
+	for header, expected := range tests {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if header != "" {
+			req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", header)
+		}
+
+		if actual := scrapeTimeout(req); actual != expected {
+			t.Errorf("Unexpected timeout for %q: %s", header, actual)
+		}
+	}
+}
+
+func TestConcurrentScrapesShareTheCollection(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			started <- struct{}{}
+			<-release
+
+			return httpmock.NewStringResponse(200, testRepositories), nil
+		})
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	targetsLock.Lock()
+	previous := activeTargets
+	activeTargets = []*Target{target}
+	targetsLock.Unlock()
+
+	defer func() {
+		targetsLock.Lock()
+		activeTargets = previous
+		targetsLock.Unlock()
+	}()
+
+	first := startCollection(time.Minute)
+	<-started
+
+	if second := startCollection(time.Minute); second != first {
+		t.Error("The scrape should wait for the collection in progress")
+	}
+
+	close(release)
+	<-first.done
+
+	if len(started) != 0 {
+		t.Error("Unexpected number of collections:", len(started)+1)
+	}
+
+	recorder := httptest.NewRecorder()
+	onDemandHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("collected"))
+	})).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if recorder.Body.String() != "collected" || len(started) != 1 {
+		t.Error("The scrape should trigger a new collection:", recorder.Body.String(), len(started))
+	}
+}
+
+func TestIncompleteCollectionKeepsTheSnapshot(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			time.Sleep(50 * time.Millisecond)
+
+			return httpmock.NewStringResponse(200, testRepositories), nil
+		})
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	targetsLock.Lock()
+	previous := activeTargets
+	activeTargets = []*Target{target}
+	targetsLock.Unlock()
+
+	snapshots = newSnapshotCollector()
+
+	defer func() {
+		snapshots = nil
+
+		targetsLock.Lock()
+		activeTargets = previous
+		targetsLock.Unlock()
+	}()
+
+	<-startCollection(10 * time.Millisecond).done
+
+	snapshots.targetsLock.Lock()
+	_, found := snapshots.targets[target.Name]
+	snapshots.targetsLock.Unlock()
+
+	if found {
+		t.Error("The snapshot should not be updated by an incomplete collection")
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+)
+
+var (
+	orgMembers = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "org_members",
+		Help:      "Number of Members of the Organization",
+	}, []string{"instance", "owner"})
+	orgAdmins = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "org_admins",
+		Help:      "Number of Admins of the Organization",
+	}, []string{"instance", "owner"})
+	orgMembersWithout2FA = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "org_members_without_2fa",
+		Help:      "Number of Members of the Organization without Two-Factor Authentication",
+	}, []string{"instance", "owner"})
+	orgPendingInvitations = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "org_pending_invitations",
+		Help:      "Number of Pending Invitations to the Organization",
+	}, []string{"instance", "owner"})
+	orgOutsideCollaborators = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "org_outside_collaborators",
+		Help:      "Number of Outside Collaborators on the Repositories of the Organization",
+	}, []string{"instance", "owner"})
+	orgTeams = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "org_teams",
+		Help:      "Number of Teams in the Organization",
+	}, []string{"instance", "owner"})
+	orgTeamMembers = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "org_team_members",
+		Help:      "Number of Members of the Team",
+	}, []string{"instance", "owner", "team"})
+)
+
+// isFilterNotAllowed returns true if the API rejected a filter that
+// only the owners of the organization can use.
+func isFilterNotAllowed(err error) bool {
+	if errResponse, ok := err.(*github.ErrorResponse); ok && errResponse.Response != nil {
+		return errResponse.Response.StatusCode == http.StatusUnprocessableEntity || isPermissionError(err)
+	}
+
+	return false
+}
+
+func countMembers(ctx context.Context, client *github.Client, org string, role, filter string) (int, error) {
+	return countAll(func(opts github.ListOptions) (int, *github.Response, error) {
+		members, resp, err := client.Organizations.ListMembers(ctx, org,
+			&github.ListMembersOptions{Role: role, Filter: filter, ListOptions: opts})
+		return len(members), resp, err
+	})
+}
+
+// collectOrganization collects the membership metrics of the organization.
+// The members without two-factor authentication and the pending invitations
+// are only visible to the owners of the organization, and they are skipped
+// for other credentials.
+func collectOrganization(ctx context.Context, target *Target, org Owner) error {
+	client := target.client
+	labels := []string{target.Name, org.Name}
+
+	members, err := countMembers(ctx, client, org.Name, "all", "")
+	if err != nil {
+		return err
+	}
+
+	target.gauge(orgMembers).WithLabelValues(labels...).Set(float64(members))
+
+	admins, err := countMembers(ctx, client, org.Name, "admin", "")
+	if err != nil {
+		return err
+	}
+
+	target.gauge(orgAdmins).WithLabelValues(labels...).Set(float64(admins))
+
+	if without2FA, err := countMembers(ctx, client, org.Name, "all", "2fa_disabled"); isFilterNotAllowed(err) {
+		target.gauge(orgMembersWithout2FA).DeleteLabelValues(labels...)
+	} else if err != nil {
+		return err
+	} else {
+		target.gauge(orgMembersWithout2FA).WithLabelValues(labels...).Set(float64(without2FA))
+	}
+
+	if invitations, err := countAll(func(opts github.ListOptions) (int, *github.Response, error) {
+		invitations, resp, err := client.Organizations.ListPendingOrgInvitations(ctx, org.Name, &opts)
+		return len(invitations), resp, err
+	}); isPermissionError(err) {
+		target.gauge(orgPendingInvitations).DeleteLabelValues(labels...)
+	} else if err != nil {
+		return err
+	} else {
+		target.gauge(orgPendingInvitations).WithLabelValues(labels...).Set(float64(invitations))
+	}
+
+	// only the owners of the organization can list the outside collaborators
+	if collaborators, err := countAll(func(opts github.ListOptions) (int, *github.Response, error) {
+		collaborators, resp, err := client.Organizations.ListOutsideCollaborators(ctx, org.Name,
+			&github.ListOutsideCollaboratorsOptions{ListOptions: opts})
+		return len(collaborators), resp, err
+	}); isPermissionError(err) {
+		target.gauge(orgOutsideCollaborators).DeleteLabelValues(labels...)
+	} else if err != nil {
+		return err
+	} else {
+		target.gauge(orgOutsideCollaborators).WithLabelValues(labels...).Set(float64(collaborators))
+	}
+
+	var teams []*github.Team
+
+	opts := github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := client.Organizations.ListTeams(ctx, org.Name, &opts)
+		if err != nil {
+			return err
+		}
+
+		teams = append(teams, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	teamMembers := map[string]int{}
+
+	for _, team := range teams {
+		id := team.GetID()
+
+		count, err := countAll(func(opts github.ListOptions) (int, *github.Response, error) {
+			members, resp, err := client.Organizations.ListTeamMembers(ctx, id,
+				&github.OrganizationListTeamMembersOptions{ListOptions: opts})
+			return len(members), resp, err
+		})
+		if err != nil {
+			return err
+		}
+
+		teamMembers[team.GetSlug()] = count
+	}
+
+	target.gauge(orgTeams).WithLabelValues(labels...).Set(float64(len(teams)))
+
+	membersByTeam := target.updateSeries(orgTeamMembers, prometheus.Labels{"instance": target.Name, "owner": org.Name})
+
+	for team, count := range teamMembers {
+		membersByTeam.WithLabelValues(target.Name, org.Name, team).Set(float64(count))
+	}
+
+	membersByTeam.done()
+
+	return nil
+}
+
+func init() {
+	register(orgMembers)
+	register(orgAdmins)
+	register(orgMembersWithout2FA)
+	register(orgPendingInvitations)
+	register(orgOutsideCollaborators)
+	register(orgTeams)
+	register(orgTeamMembers)
+
+	addOwnerCollector(OwnerCollector{Name: "org", CollectOrg: collectOrganization})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"net/http"
+	"testing"
+)
+
+func TestCollectOrganization(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	defer deleteSeries(prometheus.Labels{"owner": "docker"})
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/repos",
+		httpmock.NewStringResponder(200, `[]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/members",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("filter") == "2fa_disabled" {
+				return httpmock.NewStringResponse(422, `{"message": "Only owners can use this filter."}`), nil
+			}
+
+			if req.URL.Query().Get("role") == "admin" {
+				return httpmock.NewStringResponse(200, `[{"login": "owner"}]`), nil
+			}
+
+			if req.URL.Query().Get("page") == "2" {
+				return httpmock.NewStringResponse(200, `[{"login": "third"}]`), nil
+			}
+
+			resp := httpmock.NewStringResponse(200, `[{"login": "owner"}, {"login": "second"}]`)
+			resp.Header.Set("Link", "<https://api.github.com/orgs/docker/members?role=all&page=2>; rel=\"next\"")
+			return resp, nil
+		})
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/invitations",
+		httpmock.NewStringResponder(403, `{"message": "Must be an admin"}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/outside_collaborators",
+		httpmock.NewStringResponder(200, `[{"login": "outsider"}, {"login": "contractor"}]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/teams",
+		httpmock.NewStringResponder(200, `[{"id": 1, "slug": "core"}, {"id": 2, "slug": "docs"}]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/teams/1/members",
+		httpmock.NewStringResponder(200, `[{"login": "owner"}, {"login": "second"}]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/teams/2/members",
+		httpmock.NewStringResponder(200, `[{"login": "third"}]`))
+
+	target := &Target{Orgs: []Owner{{Name: "docker"}}, Collect: []string{"org"}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	labels := prometheus.Labels{"owner": "docker"}
+
+	expectGauge(t, "github_org_members", labels, 3)
+	expectGauge(t, "github_org_admins", labels, 1)
+	expectGauge(t, "github_org_outside_collaborators", labels, 2)
+	expectGauge(t, "github_org_teams", labels, 2)
+	expectGauge(t, "github_org_team_members", prometheus.Labels{"owner": "docker", "team": "core"}, 2)
+	expectGauge(t, "github_org_team_members", prometheus.Labels{"owner": "docker", "team": "docs"}, 1)
+
+	if _, found := gaugeValue(t, "github_org_members_without_2fa", labels); found {
+		t.Error("Unexpected metric for members without 2FA when the filter is not allowed")
+	}
+
+	if _, found := gaugeValue(t, "github_org_pending_invitations", labels); found {
+		t.Error("Unexpected metric for the pending invitations without access")
+	}
+}
+
+func TestCollectOrganizationWithoutOwnerAccess(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	defer deleteSeries(prometheus.Labels{"owner": "docker"})
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/repos",
+		httpmock.NewStringResponder(200, `[]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/members",
+		httpmock.NewStringResponder(200, `[{"login": "member"}]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/invitations",
+		httpmock.NewStringResponder(403, `{"message": "Must be an admin"}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/outside_collaborators",
+		httpmock.NewStringResponder(403, `{"message": "Must be an owner"}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/teams",
+		httpmock.NewStringResponder(200, `[{"id": 1, "slug": "core"}]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/teams/1/members",
+		httpmock.NewStringResponder(200, `[{"login": "member"}]`))
+
+	target := &Target{Orgs: []Owner{{Name: "docker"}}, Collect: []string{"org"}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	orgOutsideCollaborators.WithLabelValues(target.Name, "docker").Set(2)
+
+	collectStats(context.Background(), target)
+
+	labels := prometheus.Labels{"owner": "docker"}
+
+	expectGauge(t, "github_org_teams", labels, 1)
+	expectGauge(t, "github_org_team_members", prometheus.Labels{"owner": "docker", "team": "core"}, 1)
+
+	if _, found := gaugeValue(t, "github_org_outside_collaborators", labels); found {
+		t.Error("Unexpected metric for the outside collaborators without access")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"sync"
+)
+
+// workerPool runs functions in the background, at most size of them at the same time.
+type workerPool struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+
+	return &workerPool{slots: make(chan struct{}, size)}
+}
+
+// run starts the function when there is a free slot, and blocks until then.
+func (p *workerPool) run(f func()) {
+	p.slots <- struct{}{}
+	p.wg.Add(1)
+
+	go func() {
+		defer func() {
+			<-p.slots
+			p.wg.Done()
+		}()
+
+		f()
+	}()
+}
+
+// wait blocks until every function started has finished.
+func (p *workerPool) wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	pool := newWorkerPool(3)
+
+	var (
+		lock       sync.Mutex
+		running    = 0
+		maxRunning = 0
+		finished   = 0
+	)
+
+	for idx := 0; idx < 20; idx++ {
+		pool.run(func() {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			lock.Lock()
+			running--
+			finished++
+			lock.Unlock()
+		})
+	}
+
+	pool.wait()
+
+	if finished != 20 {
+		t.Error("Unexpected number of finished functions:", finished)
+	}
+
+	if maxRunning > 3 {
+		t.Error("Too many functions were running at the same time:", maxRunning)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log"
+	"net/http"
+)
+
+// probeHandler collects the metrics of a single user or organization,
+// given with the target and type parameters, and returns only those.
+// The instance parameter selects the configured target whose client
+// and settings to use, the first one by default.
+func probeHandler(w http.ResponseWriter, r *http.Request) {
+	name, kind := r.URL.Query().Get("target"), r.URL.Query().Get("type")
+
+	if name == "" {
+		http.Error(w, "The target parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if kind != "user" && kind != "org" {
+		http.Error(w, "The type parameter must be user or org", http.StatusBadRequest)
+		return
+	}
+
+	target := probedTarget(r.URL.Query().Get("instance"))
+	if target == nil {
+		http.Error(w, "Unknown instance: "+r.URL.Query().Get("instance"), http.StatusNotFound)
+		return
+	}
+
+	// the probe collects into metrics of its own, so the result only has the series of the owner
+	registry := prometheus.NewRegistry()
+	probe := target.probe(Owner{Name: name}, kind == "org", registry)
+
+	ctx, cancel := context.WithTimeout(r.Context(), scrapeTimeout(r))
+	defer cancel()
+
+	log.Println("Probing", name, "from", target.Name)
+
+	collectStats(ctx, probe)
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// probedTarget returns the active target with the given name,
+// or the first one if the name is empty.
+func probedTarget(name string) *Target {
+	targetsLock.Lock()
+	defer targetsLock.Unlock()
+
+	for _, target := range activeTargets {
+		if name == "" || target.Name == name {
+			return target
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProbeParameters(t *testing.T) {
+	tests := map[string]int{
+		"/probe":                          http.StatusBadRequest,
+		"/probe?target=rycus86":           http.StatusBadRequest,
+		"/probe?target=rycus86&type=team": http.StatusBadRequest,
+	}
+
+	for url, expected := range tests {
+		recorder := httptest.NewRecorder()
+		probeHandler(recorder, httptest.NewRequest("GET", url, nil))
+
+		if recorder.Code != expected {
+			t.Error("Unexpected status code for", url, ":", recorder.Code)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/probed/repos",
+		httpmock.NewStringResponder(200, `[{"name": "service", "owner": {"login": "probed"}, "forks_count": 4}]`))
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics[0].gauge.WithLabelValues(target.Name, "rycus86", "podlike").Set(3)
+
+	targetsLock.Lock()
+	previous := activeTargets
+	activeTargets = []*Target{target}
+	targetsLock.Unlock()
+
+	defer func() {
+		targetsLock.Lock()
+		activeTargets = previous
+		targetsLock.Unlock()
+	}()
+
+	recorder := httptest.NewRecorder()
+	probeHandler(recorder, httptest.NewRequest("GET", "/probe?target=probed&type=org", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatal("Unexpected status code:", recorder.Code, recorder.Body.String())
+	}
+
+	body := recorder.Body.String()
+
+	if !strings.Contains(body, `github_forks_count{instance="api.github.com",owner="probed",repository="service"} 4`) {
+		t.Error("The metrics of the probed owner are missing:", body)
+	}
+
+	if strings.Contains(body, `owner="rycus86"`) {
+		t.Error("Unexpected metrics of other owners:", body)
+	}
+
+	if _, found := gaugeValue(t, "github_forks_count", prometheus.Labels{"owner": "probed"}); found {
+		t.Error("The metrics of the probed owner should not be kept on /metrics")
+	}
+
+	recorder = httptest.NewRecorder()
+	probeHandler(recorder, httptest.NewRequest("GET", "/probe?target=probed&type=org&instance=ghe", nil))
+
+	if recorder.Code != http.StatusNotFound {
+		t.Error("Unexpected status code for an unknown instance:", recorder.Code)
+	}
+
+	// probing a configured owner does not change its metrics on /metrics
+	httpmock.RegisterResponder("GET", "https://api.github.com/users/rycus86/repos",
+		httpmock.NewStringResponder(200, `[{"name": "podlike", "owner": {"login": "rycus86"}, "forks_count": 5}]`))
+
+	recorder = httptest.NewRecorder()
+	probeHandler(recorder, httptest.NewRequest("GET", "/probe?target=rycus86&type=user", nil))
+
+	if !strings.Contains(recorder.Body.String(), `github_forks_count{instance="api.github.com",owner="rycus86",repository="podlike"} 5`) {
+		t.Error("The metrics of the probed owner are missing:", recorder.Body.String())
+	}
+
+	expectGauge(t, "github_forks_count", prometheus.Labels{"owner": "rycus86", "repository": "podlike"}, 3)
+}
+
+func TestOverlappingProbes(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	// a new response for each request, as they are read at the same time
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/probed/repos",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, `[{"name": "service", "owner": {"login": "probed"}, "forks_count": 4}]`), nil
+		})
+
+	target := &Target{}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	targetsLock.Lock()
+	previous := activeTargets
+	activeTargets = []*Target{target}
+	targetsLock.Unlock()
+
+	defer func() {
+		targetsLock.Lock()
+		activeTargets = previous
+		targetsLock.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+
+	recorders := make([]*httptest.ResponseRecorder, 5)
+
+	for idx := range recorders {
+		recorders[idx] = httptest.NewRecorder()
+
+		wg.Add(1)
+		go func(recorder *httptest.ResponseRecorder) {
+			defer wg.Done()
+
+			probeHandler(recorder, httptest.NewRequest("GET", "/probe?target=probed&type=org", nil))
+		}(recorders[idx])
+	}
+
+	wg.Wait()
+
+	for _, recorder := range recorders {
+		if !strings.Contains(recorder.Body.String(), `github_forks_count{instance="api.github.com",owner="probed",repository="service"} 4`) {
+			t.Error("The metrics of the probed owner are missing:", recorder.Body.String())
+		}
+	}
+}
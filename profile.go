@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+var (
+	ownerFollowers = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "owner_followers",
+		Help:      "Number of Followers of the User or Organization",
+	}, []string{"instance", "owner"})
+	ownerFollowing = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "owner_following",
+		Help:      "Number of Users followed by the User or Organization",
+	}, []string{"instance", "owner"})
+	ownerPublicRepos = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "owner_public_repos",
+		Help:      "Number of Public Repositories of the User or Organization",
+	}, []string{"instance", "owner"})
+	ownerPublicGists = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "owner_public_gists",
+		Help:      "Number of Public Gists of the User or Organization",
+	}, []string{"instance", "owner"})
+	ownerCreated = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "owner_created_timestamp_seconds",
+		Help:      "Time when the User or Organization was Created, in unix seconds",
+	}, []string{"instance", "owner"})
+)
+
+func updateProfile(target *Target, owner Owner, followers, following, publicRepos, publicGists int, created time.Time) {
+	labels := []string{target.Name, owner.Name}
+
+	target.gauge(ownerFollowers).WithLabelValues(labels...).Set(float64(followers))
+	target.gauge(ownerFollowing).WithLabelValues(labels...).Set(float64(following))
+	target.gauge(ownerPublicRepos).WithLabelValues(labels...).Set(float64(publicRepos))
+	target.gauge(ownerPublicGists).WithLabelValues(labels...).Set(float64(publicGists))
+
+	if !created.IsZero() {
+		target.gauge(ownerCreated).WithLabelValues(labels...).Set(float64(created.Unix()))
+	}
+}
+
+// collectUserProfile collects the numbers from the public profile of the user.
+func collectUserProfile(ctx context.Context, target *Target, user Owner) error {
+	profile, _, err := target.client.Users.Get(ctx, user.Name)
+	if err != nil {
+		return err
+	}
+
+	updateProfile(target, user,
+		profile.GetFollowers(), profile.GetFollowing(), profile.GetPublicRepos(), profile.GetPublicGists(),
+		profile.GetCreatedAt().Time)
+
+	return nil
+}
+
+// collectOrgProfile collects the numbers from the public profile of the organization.
+func collectOrgProfile(ctx context.Context, target *Target, org Owner) error {
+	profile, _, err := target.client.Organizations.Get(ctx, org.Name)
+	if err != nil {
+		return err
+	}
+
+	updateProfile(target, org,
+		profile.GetFollowers(), profile.GetFollowing(), profile.GetPublicRepos(), profile.GetPublicGists(),
+		profile.GetCreatedAt())
+
+	return nil
+}
+
+func init() {
+	register(ownerFollowers)
+	register(ownerFollowing)
+	register(ownerPublicRepos)
+	register(ownerPublicGists)
+	register(ownerCreated)
+
+	addOwnerCollector(OwnerCollector{Name: "profile", CollectUser: collectUserProfile, CollectOrg: collectOrgProfile})
+}
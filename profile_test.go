@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+func TestCollectProfile(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	defer deleteSeries(prometheus.Labels{"owner": "docker"})
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/users/rycus86",
+		httpmock.NewStringResponder(200, `{
+			"login": "rycus86", "followers": 42, "following": 7,
+			"public_repos": 59, "public_gists": 3, "created_at": "2013-05-23T16:15:07Z"
+		}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker",
+		httpmock.NewStringResponder(200, `{"login": "docker", "followers": 1200, "public_repos": 115}`))
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/users/rycus86/repos",
+		httpmock.NewStringResponder(200, testRepositories))
+	httpmock.RegisterResponder("GET", "https://api.github.com/orgs/docker/repos",
+		httpmock.NewStringResponder(200, `[]`))
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Orgs: []Owner{{Name: "docker"}}, Collect: []string{"profile"}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	collectStats(context.Background(), target)
+
+	user := prometheus.Labels{"owner": "rycus86"}
+
+	expectGauge(t, "github_owner_followers", user, 42)
+	expectGauge(t, "github_owner_following", user, 7)
+	expectGauge(t, "github_owner_public_repos", user, 59)
+	expectGauge(t, "github_owner_public_gists", user, 3)
+	expectGauge(t, "github_owner_created_timestamp_seconds", user, 1369325707)
+
+	org := prometheus.Labels{"owner": "docker"}
+
+	expectGauge(t, "github_owner_followers", org, 1200)
+	expectGauge(t, "github_owner_public_repos", org, 115)
+
+	if _, found := gaugeValue(t, "github_owner_created_timestamp_seconds", org); found {
+		t.Error("Unexpected creation time for the organization without one")
+	}
+}
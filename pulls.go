@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// the preview media type to get the draft flag of pull requests
+const mediaTypeDraftPreview = "application/vnd.github.shadow-cat-preview+json"
+
+var (
+	pullRequestsOpen = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "pull_requests_open",
+		Help:      "Number of Open Pull Requests by Base Branch",
+	}, []string{"instance", "owner", "repository", "base"})
+	pullRequestsOpenByLabel = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "pull_requests_open_by_label",
+		Help:      "Number of Open Pull Requests by Label",
+	}, []string{"instance", "owner", "repository", "label"})
+	pullRequestsDraft = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "pull_requests_draft",
+		Help:      "Number of Open Draft Pull Requests",
+	}, []string{"instance", "owner", "repository"})
+	pullRequestsAwaitingReview = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "pull_requests_awaiting_review",
+		Help:      "Number of Open Pull Requests with Pending Review Requests",
+	}, []string{"instance", "owner", "repository"})
+	pullRequestsOldestAge = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "pull_requests_oldest_open_age_seconds",
+		Help:      "Age of the Oldest Open Pull Request in seconds",
+	}, []string{"instance", "owner", "repository"})
+
+	pullRequestMergeDuration = newHistogramVec(prometheus.HistogramOpts{
+		Namespace: "github",
+		Name:      "pull_request_merge_duration_seconds",
+		Help:      "Time between opening and merging Pull Requests",
+		Buckets: []float64{
+			time.Hour.Seconds(), 6 * time.Hour.Seconds(), 24 * time.Hour.Seconds(),
+			3 * 24 * time.Hour.Seconds(), 7 * 24 * time.Hour.Seconds(), 14 * 24 * time.Hour.Seconds(),
+			30 * 24 * time.Hour.Seconds(), 90 * 24 * time.Hour.Seconds(),
+		},
+	}, []string{"instance", "owner", "repository"})
+)
+
+// pullRequest adds the fields to the pull request that
+// the vendored version of go-github does not know about yet.
+type pullRequest struct {
+	github.PullRequest
+
+	Draft              *bool           `json:"draft,omitempty"`
+	Labels             []*github.Label `json:"labels,omitempty"`
+	RequestedReviewers []*github.User  `json:"requested_reviewers,omitempty"`
+	RequestedTeams     []*github.Team  `json:"requested_teams,omitempty"`
+}
+
+func listPullRequests(ctx context.Context, client *github.Client, owner, repo, state string, opts github.ListOptions) ([]*pullRequest, *github.Response, error) {
+	u := fmt.Sprintf("repos/%v/%v/pulls?state=%s&sort=updated&direction=desc&per_page=%d&page=%d",
+		owner, repo, state, opts.PerPage, opts.Page)
+
+	req, err := client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Accept", mediaTypeDraftPreview)
+
+	var pulls []*pullRequest
+	resp, err := client.Do(ctx, req, &pulls)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return pulls, resp, nil
+}
+
+func collectPullRequests(ctx context.Context, target *Target, repository *github.Repository) error {
+	owner, name := repository.GetOwner().GetLogin(), repository.GetName()
+
+	var open []*pullRequest
+
+	opts := github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := listPullRequests(ctx, target.client, owner, name, "open", opts)
+		if err != nil {
+			return err
+		}
+
+		open = append(open, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	var (
+		byBase           = map[string]int{}
+		byLabel          = map[string]int{}
+		drafts           = 0
+		awaitingReview   = 0
+		oldest           time.Time
+		labels           = repositoryLabels(target, repository)
+		repositoryFilter = repositorySeries(target, repository)
+	)
+
+	for _, pull := range open {
+		byBase[pull.GetBase().GetRef()]++
+
+		for _, label := range pull.Labels {
+			byLabel[label.GetName()]++
+		}
+
+		if oldest.IsZero() || pull.GetCreatedAt().Before(oldest) {
+			oldest = pull.GetCreatedAt()
+		}
+
+		if pull.Draft != nil && *pull.Draft {
+			drafts++
+			continue
+		}
+
+		if len(pull.RequestedReviewers) > 0 || len(pull.RequestedTeams) > 0 {
+			awaitingReview++
+		}
+	}
+
+	openByBase := target.updateSeries(pullRequestsOpen, repositoryFilter)
+	openByLabel := target.updateSeries(pullRequestsOpenByLabel, repositoryFilter)
+
+	for base, count := range byBase {
+		openByBase.WithLabelValues(append(labels, base)...).Set(float64(count))
+	}
+
+	for label, count := range byLabel {
+		openByLabel.WithLabelValues(append(labels, label)...).Set(float64(count))
+	}
+
+	openByBase.done()
+	openByLabel.done()
+
+	target.gauge(pullRequestsDraft).WithLabelValues(labels...).Set(float64(drafts))
+	target.gauge(pullRequestsAwaitingReview).WithLabelValues(labels...).Set(float64(awaitingReview))
+
+	if oldest.IsZero() {
+		target.gauge(pullRequestsOldestAge).DeleteLabelValues(labels...)
+	} else {
+		target.gauge(pullRequestsOldestAge).WithLabelValues(labels...).Set(time.Since(oldest).Seconds())
+	}
+
+	return collectMergedPullRequests(ctx, target, repository)
+}
+
+// collectMergedPullRequests observes the time to merge of the recently
+// closed pull requests, each of them only once: the ones merged after the
+// latest one observed before, as they can show up again when updated.
+func collectMergedPullRequests(ctx context.Context, target *Target, repository *github.Repository) error {
+	closed, _, err := listPullRequests(ctx, target.client,
+		repository.GetOwner().GetLogin(), repository.GetName(), "closed", github.ListOptions{PerPage: 100})
+	if err != nil {
+		return err
+	}
+
+	key := target.Name + "/" + repository.GetFullName()
+
+	target.metrics.mergedLock.Lock()
+	defer target.metrics.mergedLock.Unlock()
+
+	observed := target.metrics.merged[key]
+	latest := observed
+
+	for _, pull := range closed {
+		if pull.MergedAt == nil || !pull.GetMergedAt().After(observed) {
+			continue
+		}
+
+		target.histogram(pullRequestMergeDuration).WithLabelValues(repositoryLabels(target, repository)...).
+			Observe(pull.GetMergedAt().Sub(pull.GetCreatedAt()).Seconds())
+
+		if pull.GetMergedAt().After(latest) {
+			latest = pull.GetMergedAt()
+		}
+	}
+
+	target.metrics.merged[key] = latest
+
+	return nil
+}
+
+func forgetMergedPullRequests(target *Target, owner, repository string) {
+	target.metrics.mergedLock.Lock()
+	defer target.metrics.mergedLock.Unlock()
+
+	delete(target.metrics.merged, target.Name+"/"+owner+"/"+repository)
+}
+
+func init() {
+	register(pullRequestsOpen)
+	register(pullRequestsOpenByLabel)
+	register(pullRequestsDraft)
+	register(pullRequestsAwaitingReview)
+	register(pullRequestsOldestAge)
+	register(pullRequestMergeDuration)
+
+	addRepositoryCollector(RepositoryCollector{Name: "pulls", Collect: collectPullRequests, Forget: forgetMergedPullRequests})
+}
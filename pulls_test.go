@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCollectPullRequests(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	// start from a clean state, in case other collections observed the pull requests already
+	pullRequestMergeDuration.Reset()
+	forgetMergedPullRequests(&Target{Name: "api.github.com", metrics: defaultMetrics}, "rycus86", "podlike")
+
+	created := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	reappeared := ""
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/pulls",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("state") == "closed" {
+				return httpmock.NewStringResponse(200, `[`+reappeared+`
+					{"number": 3, "created_at": "2018-06-01T10:00:00Z", "merged_at": "2018-06-01T12:00:00Z"},
+					{"number": 2, "created_at": "2018-05-01T10:00:00Z", "closed_at": "2018-05-02T10:00:00Z"}
+				]`), nil
+			}
+
+			return httpmock.NewStringResponse(200, `[
+				{"number": 7, "created_at": "`+created+`", "base": {"ref": "master"},
+				 "labels": [{"name": "bug"}, {"name": "help wanted"}], "requested_reviewers": [{"login": "reviewer"}]},
+				{"number": 6, "created_at": "2018-07-01T10:00:00Z", "base": {"ref": "master"}, "labels": [{"name": "bug"}],
+				 "requested_reviewers": [], "requested_teams": []},
+				{"number": 5, "created_at": "2018-07-01T10:00:00Z", "base": {"ref": "develop"}, "draft": true}
+			]`), nil
+		})
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/pulls",
+		httpmock.NewStringResponder(200, `[]`))
+
+	collectWith(t, "pulls")
+
+	// a pull request merged before the latest one observed is updated, so it is listed again
+	reappeared = `{"number": 1, "created_at": "2018-04-01T10:00:00Z", "merged_at": "2018-04-01T11:00:00Z"},`
+
+	collectWith(t, "pulls")
+
+	labels := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_pull_requests_open", prometheus.Labels{"repository": "podlike", "base": "master"}, 2)
+	expectGauge(t, "github_pull_requests_open", prometheus.Labels{"repository": "podlike", "base": "develop"}, 1)
+	expectGauge(t, "github_pull_requests_open_by_label", prometheus.Labels{"repository": "podlike", "label": "bug"}, 2)
+	expectGauge(t, "github_pull_requests_draft", labels, 1)
+	expectGauge(t, "github_pull_requests_awaiting_review", labels, 1)
+
+	if age, found := gaugeValue(t, "github_pull_requests_oldest_open_age_seconds", labels); !found {
+		t.Error("The age of the oldest pull request was not found")
+	} else if age < time.Since(time.Date(2018, 7, 1, 10, 0, 0, 0, time.UTC)).Seconds()-60 {
+		t.Error("Unexpected age of the oldest pull request:", age)
+	}
+
+	if _, found := gaugeValue(t, "github_pull_requests_oldest_open_age_seconds",
+		prometheus.Labels{"repository": "forked"}); found {
+		t.Error("Unexpected age for the repository without pull requests")
+	}
+
+	histogram := pullRequestMergeDuration.WithLabelValues("api.github.com", "rycus86", "podlike")
+
+	pb := &dto.Metric{}
+	if err := histogram.(prometheus.Metric).Write(pb); err != nil {
+		t.Fatal(err)
+	}
+
+	// the merged pull requests should only be observed once across the two collections
+	if count := pb.GetHistogram().GetSampleCount(); count != 1 {
+		t.Error("Unexpected number of merged pull requests observed:", count)
+	}
+
+	if sum := pb.GetHistogram().GetSampleSum(); sum != 7200 {
+		t.Error("Unexpected time to merge:", sum)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	releaseCount = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "release_count",
+		Help:      "Number of Releases",
+	}, []string{"instance", "owner", "repository"})
+	releaseLatestPublished = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "release_latest_published_timestamp_seconds",
+		Help:      "Publish time of the Latest Release as Unix timestamp",
+	}, []string{"instance", "owner", "repository"})
+
+	releaseDownloads = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "release_download_count",
+		Help:      "Number of Downloads of all the Assets of the Release",
+	}, []string{"instance", "owner", "repository", "tag"})
+	releasePrerelease = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "release_prerelease",
+		Help:      "Whether the Release is a Pre-release (1) or not (0)",
+	}, []string{"instance", "owner", "repository", "tag"})
+	releaseDraft = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "release_draft",
+		Help:      "Whether the Release is a Draft (1) or not (0)",
+	}, []string{"instance", "owner", "repository", "tag"})
+
+	releaseAssetDownloads = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "release_asset_download_count",
+		Help:      "Number of Downloads of the Release Asset",
+	}, []string{"instance", "owner", "repository", "tag", "asset"})
+)
+
+func collectReleases(ctx context.Context, target *Target, repository *github.Repository) error {
+	var releases []*github.RepositoryRelease
+
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := target.client.Repositories.ListReleases(
+			ctx, repository.GetOwner().GetLogin(), repository.GetName(), opts)
+		if err != nil {
+			return err
+		}
+
+		releases = append(releases, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	labels := repositoryLabels(target, repository)
+
+	var (
+		downloads      = target.updateSeries(releaseDownloads, repositorySeries(target, repository))
+		prerelease     = target.updateSeries(releasePrerelease, repositorySeries(target, repository))
+		draft          = target.updateSeries(releaseDraft, repositorySeries(target, repository))
+		assetDownloads = target.updateSeries(releaseAssetDownloads, repositorySeries(target, repository))
+	)
+
+	var latestPublished *github.Timestamp
+
+	for _, release := range releases {
+		releaseLabels := append(labels, release.GetTagName())
+
+		totalDownloads := 0
+
+		for _, asset := range release.Assets {
+			totalDownloads += asset.GetDownloadCount()
+
+			assetDownloads.WithLabelValues(append(releaseLabels, asset.GetName())...).
+				Set(float64(asset.GetDownloadCount()))
+		}
+
+		downloads.WithLabelValues(releaseLabels...).Set(float64(totalDownloads))
+		prerelease.WithLabelValues(releaseLabels...).Set(boolValue(release.GetPrerelease()))
+		draft.WithLabelValues(releaseLabels...).Set(boolValue(release.GetDraft()))
+
+		if release.PublishedAt != nil && !release.GetDraft() {
+			if latestPublished == nil || release.PublishedAt.After(latestPublished.Time) {
+				latestPublished = release.PublishedAt
+			}
+		}
+	}
+
+	for _, update := range []*seriesUpdate{downloads, prerelease, draft, assetDownloads} {
+		update.done()
+	}
+
+	target.gauge(releaseCount).WithLabelValues(labels...).Set(float64(len(releases)))
+
+	if latestPublished != nil {
+		target.gauge(releaseLatestPublished).WithLabelValues(labels...).Set(float64(latestPublished.Unix()))
+	} else {
+		target.gauge(releaseLatestPublished).DeleteLabelValues(labels...)
+	}
+
+	return nil
+}
+
+func init() {
+	register(releaseCount)
+	register(releaseLatestPublished)
+	register(releaseDownloads)
+	register(releasePrerelease)
+	register(releaseDraft)
+	register(releaseAssetDownloads)
+
+	addRepositoryCollector(RepositoryCollector{Name: "releases", Collect: collectReleases})
+}
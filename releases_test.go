@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+func TestCollectReleases(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/releases",
+		httpmock.NewStringResponder(200, `[
+			{"tag_name": "0.3.0", "prerelease": true, "published_at": "2018-07-02T10:00:00Z", "assets": [
+				{"name": "podlike_linux_amd64", "download_count": 12},
+				{"name": "podlike_linux_arm64", "download_count": 3}
+			]},
+			{"tag_name": "0.2.0", "published_at": "2018-06-01T10:00:00Z", "assets": [
+				{"name": "podlike_linux_amd64", "download_count": 40}
+			]},
+			{"tag_name": "0.4.0", "draft": true}
+		]`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/releases",
+		httpmock.NewStringResponder(200, `[]`))
+
+	collectWith(t, "releases")
+
+	labels := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_release_count", labels, 3)
+	expectGauge(t, "github_release_latest_published_timestamp_seconds", labels, 1530525600)
+
+	expectGauge(t, "github_release_download_count", prometheus.Labels{"repository": "podlike", "tag": "0.3.0"}, 15)
+	expectGauge(t, "github_release_prerelease", prometheus.Labels{"repository": "podlike", "tag": "0.3.0"}, 1)
+	expectGauge(t, "github_release_prerelease", prometheus.Labels{"repository": "podlike", "tag": "0.2.0"}, 0)
+	expectGauge(t, "github_release_draft", prometheus.Labels{"repository": "podlike", "tag": "0.4.0"}, 1)
+	expectGauge(t, "github_release_asset_download_count",
+		prometheus.Labels{"repository": "podlike", "tag": "0.3.0", "asset": "podlike_linux_arm64"}, 3)
+
+	expectGauge(t, "github_release_count", prometheus.Labels{"repository": "forked"}, 0)
+
+	if _, found := gaugeValue(t, "github_release_latest_published_timestamp_seconds",
+		prometheus.Labels{"repository": "forked"}); found {
+		t.Error("Unexpected publish time for the repository without releases")
+	}
+}
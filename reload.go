@@ -0,0 +1,141 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	activeTargets []*Target
+	targetsLock   sync.Mutex
+
+	// serializes the reloads, which also update the flags from the configuration file
+	reloadLock sync.Mutex
+)
+
+func startTargets(targets []*Target) {
+	targetsLock.Lock()
+	defer targetsLock.Unlock()
+
+	activeTargets = targets
+
+	for _, target := range targets {
+		target.start()
+	}
+}
+
+// reload reads the configuration again, and restarts the collection
+// with the new targets. The series of the users and organizations that
+// are no longer configured are removed. On errors, the current
+// configuration stays in effect.
+func reload() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	targets, err := configuredTargets()
+	if err != nil {
+		return err
+	}
+
+	// a collection triggered by a scrape would set the series of the removed owners again
+	waitForCollection()
+	defer collectionLock.Unlock()
+
+	targetsLock.Lock()
+	defer targetsLock.Unlock()
+
+	for _, target := range activeTargets {
+		target.halt()
+	}
+
+	removeSeriesOfRemovedOwners(activeTargets, targets)
+
+	for _, target := range targets {
+		for _, previous := range activeTargets {
+			if previous.Name == target.Name {
+				target.inheritSeen(previous)
+			}
+		}
+	}
+
+	activeTargets = targets
+
+	for _, target := range targets {
+		target.start()
+	}
+
+	return nil
+}
+
+func removeSeriesOfRemovedOwners(previous, current []*Target) {
+	currentOwners := map[string]map[string]bool{}
+
+	for _, target := range current {
+		owners := map[string]bool{}
+
+		for _, owner := range target.owners() {
+			owners[owner.Name] = true
+		}
+
+		currentOwners[target.Name] = owners
+	}
+
+	for _, target := range previous {
+		owners, found := currentOwners[target.Name]
+		if !found {
+			log.Println("Removing the metrics of", target.Name)
+
+			deleteSeries(prometheus.Labels{"instance": target.Name})
+			removeSnapshot(target.Name)
+			continue
+		}
+
+		for _, owner := range target.owners() {
+			if !owners[owner.Name] {
+				log.Println("Removing the metrics of", owner.Name, "from", target.Name)
+
+				deleteSeries(prometheus.Labels{"instance": target.Name, "owner": owner.Name})
+				removeFromSnapshot(target.Name, prometheus.Labels{"owner": owner.Name})
+
+				// the series of the repositories have the login from the API
+				if login := target.loginOf(owner.Name); login != owner.Name {
+					deleteSeries(prometheus.Labels{"instance": target.Name, "owner": login})
+					removeFromSnapshot(target.Name, prometheus.Labels{"owner": login})
+				}
+			}
+		}
+	}
+}
+
+func reloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		log.Println("Reloading the configuration")
+
+		if err := reload(); err != nil {
+			log.Println("Failed to reload the configuration:", err)
+		}
+	}
+}
+
+func reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	log.Println("Reloading the configuration")
+
+	if err := reload(); err != nil {
+		log.Println("Failed to reload the configuration:", err)
+		http.Error(w, "Failed to reload the configuration: "+err.Error(), http.StatusInternalServerError)
+	}
+}
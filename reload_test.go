@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemoveSeriesOfRemovedOwners(t *testing.T) {
+	repoCount.Reset()
+	repoInfo.Reset()
+	for _, m := range metrics {
+		m.gauge.Reset()
+	}
+
+	repoCount.WithLabelValues("api.github.com", "rycus86").Set(59)
+	repoCount.WithLabelValues("api.github.com", "docker").Set(30)
+	repoCount.WithLabelValues("ghe", "platform").Set(12)
+	metrics[0].gauge.WithLabelValues("api.github.com", "rycus86", "podlike").Set(1)
+	metrics[0].gauge.WithLabelValues("api.github.com", "docker", "compose").Set(2)
+	rateLimit.WithLabelValues("ghe").Set(5000)
+
+	previous := []*Target{
+		{Name: "api.github.com", Users: []Owner{{Name: "rycus86"}}, Orgs: []Owner{{Name: "docker"}}},
+		{Name: "ghe", Orgs: []Owner{{Name: "platform"}}},
+	}
+	current := []*Target{
+		{Name: "api.github.com", Orgs: []Owner{{Name: "docker"}}},
+	}
+
+	removeSeriesOfRemovedOwners(previous, current)
+
+	gathered, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var remaining []string
+
+	for _, g := range gathered {
+		if !strings.HasPrefix(g.GetName(), "github_") {
+			continue
+		}
+
+		for _, m := range g.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "owner" || (label.GetName() == "instance" && label.GetValue() == "ghe") {
+					remaining = append(remaining, g.GetName()+":"+label.GetValue())
+				}
+			}
+		}
+	}
+
+	if strings.Join(remaining, ",") != "github_"+metrics[0].Name+":docker,github_repo_count:docker" {
+		t.Error("Unexpected series remaining:", remaining)
+	}
+}
+
+func TestReloadHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	reloadHandler(recorder, httptest.NewRequest("GET", "/-/reload", nil))
+
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Error("Unexpected status code:", recorder.Code)
+	}
+
+	tf, err := ioutil.TempFile("", "gh-exporter-config")
+	if err != nil {
+		t.Fatal("Failed to create a temporary file:", err)
+	}
+	defer os.Remove(tf.Name())
+
+	tf.WriteString(`{"orgs": [{"skip_forks": true}]}`)
+	tf.Close()
+
+	*configFile = tf.Name()
+	defer func() { *configFile = "" }()
+
+	recorder = httptest.NewRecorder()
+	reloadHandler(recorder, httptest.NewRequest("POST", "/-/reload", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Error("Unexpected status code:", recorder.Code)
+	}
+
+	if !strings.Contains(recorder.Body.String(), "orgs[0]: the name of the owner is required") {
+		t.Error("Unexpected response:", recorder.Body.String())
+	}
+}
+
+func TestReloadedTargetsInheritTheSeenRepositories(t *testing.T) {
+	now := time.Now()
+
+	previous := &Target{Name: "api.github.com", Users: []Owner{{Name: "rycus86"}}, Orgs: []Owner{{Name: "docker"}}}
+	previous.markSeen("rycus86", map[string]bool{"podlike": true, "removed": true}, now.Add(-time.Hour))
+	previous.markSeen("docker", map[string]bool{"compose": true}, now.Add(-time.Hour))
+
+	current := &Target{
+		Name:             "api.github.com",
+		Users:            []Owner{{Name: "rycus86"}},
+		StaleGracePeriod: Duration{time.Hour},
+	}
+	current.inheritSeen(previous)
+
+	if stale := current.markSeen("rycus86", map[string]bool{"podlike": true}, now); len(stale) != 1 || stale[0] != "removed" {
+		t.Error("Unexpected stale repositories:", stale)
+	}
+
+	if _, found := current.seenRepositories("docker"); found {
+		t.Error("The repositories of the owners no longer configured should not be inherited")
+	}
+}
+
+func TestReloadWaitsForTheScrapeCollection(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			started <- struct{}{}
+			<-release
+
+			return httpmock.NewStringResponse(200, testRepositories), nil
+		})
+
+	flag.Set("on-demand", "true")
+	defer func() { *onDemandMode = false }()
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	targetsLock.Lock()
+	previous := activeTargets
+	activeTargets = []*Target{target}
+	targetsLock.Unlock()
+
+	defer func() {
+		targetsLock.Lock()
+		activeTargets = previous
+		targetsLock.Unlock()
+	}()
+
+	path := writeConfig(t, `{"users": ["docker"]}`)
+	defer os.Remove(path)
+
+	flag.Set("config", path)
+	defer func() { *configFile = "" }()
+
+	startCollection(time.Minute)
+	<-started
+
+	reloaded := make(chan error)
+	go func() {
+		reloaded <- reload()
+	}()
+
+	select {
+	case <-reloaded:
+		t.Error("The reload should wait for the collection in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	if err := <-reloaded; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := gaugeValue(t, "github_repo_count", prometheus.Labels{"owner": "rycus86"}); found {
+		t.Error("The series of the removed owner should not be set again by the collection")
+	}
+}
+
+func TestRemovedOwnersAreRemovedFromTheSnapshot(t *testing.T) {
+	metrics[0].gauge.WithLabelValues("api.github.com", "rycus86", "podlike").Set(1)
+	metrics[0].gauge.WithLabelValues("api.github.com", "docker", "compose").Set(2)
+
+	snapshots = newSnapshotCollector()
+	defer func() { snapshots = nil }()
+
+	previous := &Target{Name: "api.github.com", Users: []Owner{{Name: "rycus86"}}, Orgs: []Owner{{Name: "docker"}}}
+	previous.markSeen("rycus86", map[string]bool{"podlike": true}, time.Now())
+	previous.markSeen("docker", map[string]bool{"compose": true}, time.Now())
+
+	updateSnapshot(previous)
+
+	current := &Target{Name: "api.github.com", Orgs: []Owner{{Name: "docker"}}}
+
+	removeSeriesOfRemovedOwners([]*Target{previous}, []*Target{current})
+
+	owners := map[string]bool{}
+
+	for _, m := range snapshots.current.Load().([]prometheus.Metric) {
+		for _, label := range m.(frozenMetric).metric.GetLabel() {
+			if label.GetName() == "owner" {
+				owners[label.GetValue()] = true
+			}
+		}
+	}
+
+	if owners["rycus86"] || !owners["docker"] {
+		t.Error("Unexpected owners in the snapshot:", owners)
+	}
+}
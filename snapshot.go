@@ -0,0 +1,168 @@
+package main
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"sync"
+	"sync/atomic"
+)
+
+// snapshotCollector serves the metrics of the last complete collection
+// of each target, instead of the metric vectors being updated by the
+// collection in progress. The snapshot of a target only has the series
+// of the repositories listed within the stale grace period, so deleted
+// and renamed repositories drop out of it.
+type snapshotCollector struct {
+	// the snapshots by target name
+	targets     map[string][]prometheus.Metric
+	targetsLock sync.Mutex
+
+	// every snapshot combined, swapped atomically on updates
+	current atomic.Value
+}
+
+// frozenMetric is a copy of a series at the time of the snapshot.
+type frozenMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+func (m frozenMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+func (m frozenMetric) Write(out *dto.Metric) error {
+	proto.Merge(out, m.metric)
+	return nil
+}
+
+// snapshots is only set when the metrics are served from snapshots
+var snapshots *snapshotCollector
+
+func newSnapshotCollector() *snapshotCollector {
+	collector := &snapshotCollector{targets: map[string][]prometheus.Metric{}}
+	collector.current.Store([]prometheus.Metric(nil))
+	return collector
+}
+
+// enableSnapshots registers the snapshot collector in place of the metric vectors.
+func enableSnapshots() {
+	snapshots = newSnapshotCollector()
+
+	for _, vec := range metricVecs {
+		prometheus.Unregister(vec)
+	}
+
+	prometheus.MustRegister(snapshots)
+}
+
+func (c *snapshotCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, vec := range metricVecs {
+		vec.Describe(ch)
+	}
+}
+
+func (c *snapshotCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.current.Load().([]prometheus.Metric) {
+		ch <- m
+	}
+}
+
+// update takes a new snapshot of the series of the target.
+func (c *snapshotCollector) update(target *Target) {
+	var frozen []prometheus.Metric
+
+	for _, vec := range metricVecs {
+		for _, s := range seriesOf(vec) {
+			if s.Labels["instance"] != target.Name {
+				continue
+			}
+
+			if owner, hasOwner := s.Labels["owner"]; hasOwner {
+				repositories, found := target.seenRepositories(owner)
+				if !found {
+					continue
+				}
+
+				if repository, hasRepository := s.Labels["repository"]; hasRepository && !repositories[repository] {
+					continue
+				}
+			}
+
+			frozen = append(frozen, frozenMetric{desc: s.Desc, metric: s.Metric})
+		}
+	}
+
+	c.targetsLock.Lock()
+	defer c.targetsLock.Unlock()
+
+	c.targets[target.Name] = frozen
+	c.swap()
+}
+
+// remove drops the snapshot of the target.
+func (c *snapshotCollector) remove(name string) {
+	c.targetsLock.Lock()
+	defer c.targetsLock.Unlock()
+
+	delete(c.targets, name)
+	c.swap()
+}
+
+// removeSeries drops the series from the snapshot of the target
+// that have all the given labels with the given values.
+func (c *snapshotCollector) removeSeries(name string, labels prometheus.Labels) {
+	c.targetsLock.Lock()
+	defer c.targetsLock.Unlock()
+
+	var kept []prometheus.Metric
+
+	for _, m := range c.targets[name] {
+		seriesLabels := prometheus.Labels{}
+		for _, pair := range m.(frozenMetric).metric.GetLabel() {
+			seriesLabels[pair.GetName()] = pair.GetValue()
+		}
+
+		if !hasLabels(seriesLabels, labels) {
+			kept = append(kept, m)
+		}
+	}
+
+	c.targets[name] = kept
+	c.swap()
+}
+
+// swap combines the snapshots of the targets, and replaces the current one,
+// while holding the targets lock.
+func (c *snapshotCollector) swap() {
+	var combined []prometheus.Metric
+
+	for _, frozen := range c.targets {
+		combined = append(combined, frozen...)
+	}
+
+	c.current.Store(combined)
+}
+
+// updateSnapshot takes a new snapshot of the target, if snapshots are enabled.
+func updateSnapshot(target *Target) {
+	if snapshots != nil {
+		snapshots.update(target)
+	}
+}
+
+// removeSnapshot drops the snapshot of the target, if snapshots are enabled.
+func removeSnapshot(name string) {
+	if snapshots != nil {
+		snapshots.remove(name)
+	}
+}
+
+// removeFromSnapshot drops the series with the given labels from the
+// snapshot of the target, if snapshots are enabled.
+func removeFromSnapshot(name string, labels prometheus.Labels) {
+	if snapshots != nil {
+		snapshots.removeSeries(name, labels)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+func TestSnapshotCollector(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/users/rycus86/repos",
+		httpmock.NewStringResponder(200, `[{"name": "podlike", "owner": {"login": "rycus86"}, "forks_count": 3}]`))
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a series of a repository that does not exist anymore
+	metrics[0].gauge.WithLabelValues(target.Name, "rycus86", "deleted").Set(1)
+	defer metrics[0].gauge.DeleteLabelValues(target.Name, "rycus86", "deleted")
+
+	collector := newSnapshotCollector()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	snapshotValue := func(repository string) (float64, bool) {
+		gathered, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, g := range gathered {
+			if g.GetName() != "github_forks_count" {
+				continue
+			}
+
+			for _, m := range g.GetMetric() {
+				if labelMatches(m.GetLabel(), "repository", repository) {
+					return m.GetGauge().GetValue(), true
+				}
+			}
+		}
+
+		return 0, false
+	}
+
+	if _, found := snapshotValue("podlike"); found {
+		t.Error("Unexpected metrics before the first snapshot")
+	}
+
+	collectStats(context.Background(), target)
+	collector.update(target)
+
+	if value, found := snapshotValue("podlike"); !found || value != 3 {
+		t.Error("Unexpected value in the snapshot:", value, found)
+	}
+
+	if _, found := snapshotValue("deleted"); found {
+		t.Error("The repository not seen in the last collection should not be in the snapshot")
+	}
+
+	// updates during the next collection are not visible until it completes
+	metrics[0].gauge.WithLabelValues(target.Name, "rycus86", "podlike").Set(5)
+
+	if value, _ := snapshotValue("podlike"); value != 3 {
+		t.Error("The snapshot should not change during the collection:", value)
+	}
+
+	collector.update(target)
+
+	if value, _ := snapshotValue("podlike"); value != 5 {
+		t.Error("The snapshot should be updated after the collection:", value)
+	}
+
+	collector.remove(target.Name)
+
+	if _, found := snapshotValue("podlike"); found {
+		t.Error("Unexpected metrics after removing the snapshot of the target")
+	}
+}
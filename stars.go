@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+var (
+	stargazersGained = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "stargazers_gained",
+		Help:      "Number of Stars gained in the time window",
+	}, []string{"instance", "owner", "repository", "window"})
+	stargazersLatest = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "stargazers_latest_timestamp_seconds",
+		Help:      "Time of the most recent Star, in unix seconds",
+	}, []string{"instance", "owner", "repository"})
+
+	starWindows = []struct {
+		Name     string
+		Duration time.Duration
+	}{
+		{"24h", 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"30d", 30 * 24 * time.Hour},
+	}
+)
+
+type starHistory struct {
+	// the time of the stars within the longest window
+	Recent []time.Time
+	// the time of the most recent star, and the stargazers who starred then
+	Latest       time.Time
+	LatestLogins []string
+}
+
+// collectStars collects the stars gained recently. Stargazers are listed
+// oldest first, so only the last page is requested on each collection,
+// and the ones before it while they have new stars within the longest window.
+func collectStars(ctx context.Context, target *Target, repository *github.Repository) error {
+	key := target.Name + "/" + repository.GetFullName()
+
+	target.metrics.starsLock.Lock()
+	history, found := target.metrics.stars[key]
+	target.metrics.starsLock.Unlock()
+
+	if !found {
+		history = &starHistory{}
+	}
+
+	history, err := listNewStargazers(ctx, target.client, repository, *history)
+	if err != nil {
+		return err
+	}
+
+	oldest := time.Now().Add(-starWindows[len(starWindows)-1].Duration)
+
+	var recent []time.Time
+	for _, starredAt := range history.Recent {
+		if starredAt.After(oldest) {
+			recent = append(recent, starredAt)
+		}
+	}
+	history.Recent = recent
+
+	target.metrics.starsLock.Lock()
+	target.metrics.stars[key] = history
+	target.metrics.starsLock.Unlock()
+
+	labels := repositoryLabels(target, repository)
+
+	for _, window := range starWindows {
+		since := time.Now().Add(-window.Duration)
+
+		gained := 0
+		for _, starredAt := range history.Recent {
+			if starredAt.After(since) {
+				gained++
+			}
+		}
+
+		target.gauge(stargazersGained).WithLabelValues(append(labels, window.Name)...).Set(float64(gained))
+	}
+
+	if !history.Latest.IsZero() {
+		target.gauge(stargazersLatest).WithLabelValues(labels...).Set(float64(history.Latest.Unix()))
+	}
+
+	return nil
+}
+
+// listNewStargazers lists the stargazers who starred the repository after
+// the most recent star seen, from the last page backwards. Comparing the
+// stargazers instead of their number finds the new ones even when others
+// have removed their stars since. Stars older than the longest window are
+// not counted, so the pages before the one that has these are not listed.
+func listNewStargazers(ctx context.Context, client *github.Client, repository *github.Repository, history starHistory) (*starHistory, error) {
+	// the last page, if the number of stargazers is up to date
+	page := (repository.GetStargazersCount()-1)/100 + 1
+
+	oldest := time.Now().Add(-starWindows[len(starWindows)-1].Duration)
+
+	var newStargazers []*github.Stargazer
+
+	for {
+		stargazers, _, err := client.Activity.ListStargazers(ctx,
+			repository.GetOwner().GetLogin(), repository.GetName(), &github.ListOptions{PerPage: 100, Page: page})
+		if err != nil {
+			return nil, err
+		}
+
+		first := len(stargazers)
+		for first > 0 && history.isNew(stargazers[first-1]) {
+			first--
+		}
+
+		newStargazers = append(stargazers[first:], newStargazers...)
+
+		// the previous page can only have new stars if every one on this page is new,
+		// and it only has stars within the windows if this one has none before them
+		if first > 0 || page <= 1 {
+			break
+		} else if len(stargazers) > 0 && stargazers[0].GetStarredAt().Time.Before(oldest) {
+			break
+		}
+
+		page--
+	}
+
+	for _, stargazer := range newStargazers {
+		starredAt := stargazer.GetStarredAt().Time
+
+		history.Recent = append(history.Recent, starredAt)
+
+		if starredAt.After(history.Latest) {
+			history.Latest = starredAt
+			history.LatestLogins = nil
+		}
+
+		history.LatestLogins = append(history.LatestLogins, stargazer.GetUser().GetLogin())
+	}
+
+	return &history, nil
+}
+
+// isNew returns whether the stargazer starred after the most recent star seen.
+func (h *starHistory) isNew(stargazer *github.Stargazer) bool {
+	starredAt := stargazer.GetStarredAt().Time
+
+	if !starredAt.Equal(h.Latest) {
+		return starredAt.After(h.Latest)
+	}
+
+	for _, login := range h.LatestLogins {
+		if login == stargazer.GetUser().GetLogin() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func forgetStars(target *Target, owner, repository string) {
+	target.metrics.starsLock.Lock()
+	defer target.metrics.starsLock.Unlock()
+
+	delete(target.metrics.stars, target.Name+"/"+owner+"/"+repository)
+}
+
+func init() {
+	register(stargazersGained)
+	register(stargazersLatest)
+
+	addRepositoryCollector(RepositoryCollector{Name: "stars", Collect: collectStars, Forget: forgetStars})
+}
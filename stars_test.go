@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectStars(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	// 100 old stars on the first page, then another old one and the recent ones
+	starredAt := []time.Time{}
+	for idx := 0; idx < 101; idx++ {
+		starredAt = append(starredAt, time.Now().Add(-400*24*time.Hour))
+	}
+	starredAt = append(starredAt, time.Now().Add(-10*24*time.Hour), time.Now().Add(-3*24*time.Hour))
+
+	var requestedPages []string
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/users/rycus86/repos",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, fmt.Sprintf(`[
+				{"name": "podlike", "full_name": "rycus86/podlike", "owner": {"login": "rycus86"}, "stargazers_count": %d}
+			]`, len(starredAt))), nil
+		})
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/stargazers",
+		func(req *http.Request) (*http.Response, error) {
+			page := req.URL.Query().Get("page")
+			requestedPages = append(requestedPages, page)
+
+			from, to := 0, 100
+			if page == "2" {
+				from, to = 100, len(starredAt)
+			}
+
+			var stargazers []string
+			for _, at := range starredAt[from:to] {
+				stargazers = append(stargazers, fmt.Sprintf(`{"starred_at": "%s", "user": {"login": "user-%d"}}`,
+					at.UTC().Format(time.RFC3339), at.UnixNano()))
+			}
+
+			return httpmock.NewStringResponse(200, "["+strings.Join(stargazers, ",")+"]"), nil
+		})
+
+	target := &Target{Users: []Owner{{Name: "rycus86"}}, Collect: []string{"stars"}}
+	if err := target.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	forgetStars(target, "rycus86", "podlike")
+
+	collectStats(context.Background(), target)
+
+	window := func(name string) prometheus.Labels {
+		return prometheus.Labels{"repository": "podlike", "window": name}
+	}
+
+	expectGauge(t, "github_stargazers_gained", window("24h"), 0)
+	expectGauge(t, "github_stargazers_gained", window("7d"), 1)
+	expectGauge(t, "github_stargazers_gained", window("30d"), 2)
+	expectGauge(t, "github_stargazers_latest_timestamp_seconds", prometheus.Labels{"repository": "podlike"},
+		float64(starredAt[102].Unix()))
+
+	if strings.Join(requestedPages, ",") != "2" {
+		t.Error("The pages before the stars within the windows should not be requested:", requestedPages)
+	}
+
+	requestedPages = nil
+	collectStats(context.Background(), target)
+
+	if strings.Join(requestedPages, ",") != "2" {
+		t.Error("Only the last page should be requested without new stars:", requestedPages)
+	}
+
+	expectGauge(t, "github_stargazers_gained", window("30d"), 2)
+
+	requestedPages = nil
+	starredAt = append(starredAt, time.Now().Add(-time.Hour))
+	collectStats(context.Background(), target)
+
+	if strings.Join(requestedPages, ",") != "2" {
+		t.Error("Only the page with the new stars should be requested:", requestedPages)
+	}
+
+	expectGauge(t, "github_stargazers_gained", window("24h"), 1)
+	expectGauge(t, "github_stargazers_gained", window("30d"), 3)
+
+	// an old star is removed and a new one is added, so the number does not change
+	requestedPages = nil
+	starredAt = append(starredAt[1:], time.Now().Add(-time.Minute))
+	collectStats(context.Background(), target)
+
+	if strings.Join(requestedPages, ",") != "2" {
+		t.Error("Only the last page should be requested:", requestedPages)
+	}
+
+	expectGauge(t, "github_stargazers_gained", window("24h"), 2)
+	expectGauge(t, "github_stargazers_gained", window("30d"), 4)
+
+	// more stars are removed than added
+	requestedPages = nil
+	starredAt = starredAt[2:]
+	collectStats(context.Background(), target)
+
+	if strings.Join(requestedPages, ",") != "2" {
+		t.Error("The stargazers should not be requested again after stars were removed:", requestedPages)
+	}
+
+	expectGauge(t, "github_stargazers_gained", window("30d"), 4)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"log"
+)
+
+var (
+	statsCommitsLastWeek = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "stats_commits_last_week",
+		Help:      "Number of Commits in the last complete week",
+	}, []string{"instance", "owner", "repository"})
+	statsCommitsLastYear = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "stats_commits_last_year",
+		Help:      "Number of Commits in the last 52 weeks",
+	}, []string{"instance", "owner", "repository"})
+	statsAdditionsLastWeek = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "stats_additions_last_week",
+		Help:      "Number of Lines Added in the last complete week",
+	}, []string{"instance", "owner", "repository"})
+	statsDeletionsLastWeek = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "stats_deletions_last_week",
+		Help:      "Number of Lines Deleted in the last complete week",
+	}, []string{"instance", "owner", "repository"})
+	statsParticipationLastWeek = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "stats_participation_commits_last_week",
+		Help:      "Number of Commits in the last complete week by the Owner or by All contributors",
+	}, []string{"instance", "owner", "repository", "participant"})
+)
+
+// isPendingStatistics returns true if GitHub is still computing the
+// statistics, and they should be requested again later.
+func isPendingStatistics(err error) bool {
+	_, ok := err.(*github.AcceptedError)
+	return ok
+}
+
+// collectStatistics collects the metrics from the repository statistics API.
+// Statistics that GitHub is still computing keep their previous values,
+// and are requested again on the next collection.
+func collectStatistics(ctx context.Context, target *Target, repository *github.Repository) error {
+	owner, name := repository.GetOwner().GetLogin(), repository.GetName()
+	labels := repositoryLabels(target, repository)
+
+	pending := false
+
+	if activity, _, err := target.client.Repositories.ListCommitActivity(ctx, owner, name); isPendingStatistics(err) {
+		pending = true
+	} else if err != nil {
+		return err
+	} else if len(activity) > 1 {
+		total := 0
+		for _, week := range activity {
+			total += week.GetTotal()
+		}
+
+		// the last week is the current one, which is not complete yet
+		target.gauge(statsCommitsLastWeek).WithLabelValues(labels...).Set(float64(activity[len(activity)-2].GetTotal()))
+		target.gauge(statsCommitsLastYear).WithLabelValues(labels...).Set(float64(total))
+	}
+
+	if frequency, _, err := target.client.Repositories.ListCodeFrequency(ctx, owner, name); isPendingStatistics(err) {
+		pending = true
+	} else if err != nil {
+		return err
+	} else if len(frequency) > 1 {
+		lastWeek := frequency[len(frequency)-2]
+
+		target.gauge(statsAdditionsLastWeek).WithLabelValues(labels...).Set(float64(lastWeek.GetAdditions()))
+		// deletions are reported as negative numbers
+		target.gauge(statsDeletionsLastWeek).WithLabelValues(labels...).Set(float64(-lastWeek.GetDeletions()))
+	}
+
+	if participation, _, err := target.client.Repositories.ListParticipation(ctx, owner, name); isPendingStatistics(err) {
+		pending = true
+	} else if err != nil {
+		return err
+	} else if len(participation.All) > 1 && len(participation.Owner) > 1 {
+		target.gauge(statsParticipationLastWeek).WithLabelValues(append(labels, "all")...).
+			Set(float64(participation.All[len(participation.All)-2]))
+		target.gauge(statsParticipationLastWeek).WithLabelValues(append(labels, "owner")...).
+			Set(float64(participation.Owner[len(participation.Owner)-2]))
+	}
+
+	if pending {
+		log.Println("Statistics for", repository.GetFullName(), "are being computed, retrying on the next collection")
+	}
+
+	return nil
+}
+
+func init() {
+	register(statsCommitsLastWeek)
+	register(statsCommitsLastYear)
+	register(statsAdditionsLastWeek)
+	register(statsDeletionsLastWeek)
+	register(statsParticipationLastWeek)
+
+	addRepositoryCollector(RepositoryCollector{Name: "stats", Collect: collectStatistics})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+func TestCollectStatistics(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	base := "https://api.github.com/repos/rycus86/podlike/stats"
+
+	httpmock.RegisterResponder("GET", base+"/commit_activity",
+		httpmock.NewStringResponder(200, `[{"total": 10, "week": 1529798400}, {"total": 7, "week": 1530403200}, {"total": 2, "week": 1531008000}]`))
+	httpmock.RegisterResponder("GET", base+"/code_frequency",
+		httpmock.NewStringResponder(200, `[[1529798400, 500, -20], [1530403200, 120, -45], [1531008000, 3, 0]]`))
+	httpmock.RegisterResponder("GET", base+"/participation",
+		httpmock.NewStringResponder(200, `{"all": [4, 9, 1], "owner": [2, 6, 1]}`))
+
+	for _, endpoint := range []string{"commit_activity", "code_frequency", "participation"} {
+		httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/stats/"+endpoint,
+			httpmock.NewStringResponder(202, `{}`))
+	}
+
+	collectWith(t, "stats")
+
+	labels := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_stats_commits_last_week", labels, 7)
+	expectGauge(t, "github_stats_commits_last_year", labels, 19)
+	expectGauge(t, "github_stats_additions_last_week", labels, 120)
+	expectGauge(t, "github_stats_deletions_last_week", labels, 45)
+	expectGauge(t, "github_stats_participation_commits_last_week",
+		prometheus.Labels{"repository": "podlike", "participant": "all"}, 9)
+	expectGauge(t, "github_stats_participation_commits_last_week",
+		prometheus.Labels{"repository": "podlike", "participant": "owner"}, 6)
+
+	// statistics being computed should not be recorded as zeros
+	if _, found := gaugeValue(t, "github_stats_commits_last_week", prometheus.Labels{"repository": "forked"}); found {
+		t.Error("Unexpected statistics while they are being computed")
+	}
+
+	// the previous values are kept while the statistics are computed again
+	httpmock.RegisterResponder("GET", base+"/commit_activity", httpmock.NewStringResponder(202, `{}`))
+
+	collectWith(t, "stats")
+
+	expectGauge(t, "github_stats_commits_last_week", labels, 7)
+}
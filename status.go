@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+var (
+	defaultBranchStatus = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "default_branch_status",
+		Help:      "Combined Status of the HEAD of the Default Branch, 1 for the current state and 0 for the others",
+	}, []string{"instance", "owner", "repository", "state"})
+	defaultBranchFailingContexts = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "default_branch_failing_contexts",
+		Help:      "Number of failing or erroring Status Contexts of the HEAD of the Default Branch",
+	}, []string{"instance", "owner", "repository"})
+	defaultBranchHeadAge = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "default_branch_head_age_seconds",
+		Help:      "Age of the HEAD Commit of the Default Branch in seconds",
+	}, []string{"instance", "owner", "repository"})
+
+	statusStates = []string{"success", "failure", "pending", "error"}
+)
+
+// collectStatus collects the combined status of the commit
+// at the HEAD of the default branch of the repository.
+func collectStatus(ctx context.Context, target *Target, repository *github.Repository) error {
+	owner, name := repository.GetOwner().GetLogin(), repository.GetName()
+	labels := repositoryLabels(target, repository)
+
+	branch, _, err := target.client.Repositories.GetBranch(ctx, owner, name, repository.GetDefaultBranch())
+	if err != nil {
+		return err
+	}
+
+	head := branch.GetCommit()
+
+	var (
+		state   string
+		failing = 0
+	)
+
+	opts := github.ListOptions{PerPage: 100}
+
+	for {
+		status, resp, err := target.client.Repositories.GetCombinedStatus(ctx, owner, name, head.GetSHA(), &opts)
+		if err != nil {
+			return err
+		}
+
+		state = status.GetState()
+
+		for _, contextStatus := range status.Statuses {
+			if contextStatus.GetState() == "failure" || contextStatus.GetState() == "error" {
+				failing++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	for _, known := range statusStates {
+		target.gauge(defaultBranchStatus).WithLabelValues(append(labels, known)...).Set(boolValue(known == state))
+	}
+
+	target.gauge(defaultBranchFailingContexts).WithLabelValues(labels...).Set(float64(failing))
+
+	if committed := head.GetCommit().GetCommitter().GetDate(); !committed.IsZero() {
+		target.gauge(defaultBranchHeadAge).WithLabelValues(labels...).Set(time.Since(committed).Seconds())
+	}
+
+	return nil
+}
+
+func init() {
+	register(defaultBranchStatus)
+	register(defaultBranchFailingContexts)
+	register(defaultBranchHeadAge)
+
+	addRepositoryCollector(RepositoryCollector{Name: "status", Collect: collectStatus})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+	"time"
+)
+
+func TestCollectStatus(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	committed := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/branches/master",
+		httpmock.NewStringResponder(200, fmt.Sprintf(`{"name": "master", "commit": {
+			"sha": "abc123", "commit": {"committer": {"date": "%s"}}
+		}}`, committed)))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/podlike/commits/abc123/status",
+		httpmock.NewStringResponder(200, `{"state": "failure", "sha": "abc123", "statuses": [
+			{"context": "ci/build", "state": "success"},
+			{"context": "ci/test", "state": "failure"},
+			{"context": "ci/lint", "state": "error"}
+		]}`))
+
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/branches/master",
+		httpmock.NewStringResponder(200, `{"name": "master", "commit": {"sha": "def456"}}`))
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/commits/def456/status",
+		httpmock.NewStringResponder(200, `{"state": "success", "sha": "def456", "statuses": []}`))
+
+	collectWith(t, "status")
+
+	podlike := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_default_branch_status", prometheus.Labels{"repository": "podlike", "state": "failure"}, 1)
+	expectGauge(t, "github_default_branch_status", prometheus.Labels{"repository": "podlike", "state": "success"}, 0)
+	expectGauge(t, "github_default_branch_failing_contexts", podlike, 2)
+
+	if age, found := gaugeValue(t, "github_default_branch_head_age_seconds", podlike); !found {
+		t.Error("The age of the HEAD commit is missing")
+	} else if age < 7190 || age > 7300 {
+		t.Error("Unexpected age of the HEAD commit:", age)
+	}
+
+	expectGauge(t, "github_default_branch_status", prometheus.Labels{"repository": "forked", "state": "success"}, 1)
+	expectGauge(t, "github_default_branch_failing_contexts", prometheus.Labels{"repository": "forked"}, 0)
+}
@@ -0,0 +1,599 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/github"
+	"github.com/gregjones/httpcache"
+	"github.com/prometheus/client_golang/prometheus"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultInstance = "api.github.com"
+
+// Target is a GitHub (or GitHub Enterprise) API endpoint, together with
+// the credentials to use for it and the owners to collect metrics for.
+type Target struct {
+	// Name is used as the value of the instance label on the metrics
+	Name string `json:"name"`
+
+	APIURL             string `json:"api_url"`
+	UploadURL          string `json:"upload_url"`
+	CAFile             string `json:"ca_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+
+	Username          string `json:"username"`
+	Password          string `json:"password"`
+	CredentialsFile   string `json:"credentials"`
+	Token             string `json:"token"`
+	TokenFile         string `json:"token_file"`
+	AppID             int64  `json:"app_id"`
+	AppInstallationID int64  `json:"app_installation_id"`
+	AppKeyFile        string `json:"app_key"`
+
+	Users     []Owner  `json:"users"`
+	Orgs      []Owner  `json:"orgs"`
+	SkipForks *bool    `json:"skip_forks"`
+	Interval  Duration `json:"interval"`
+	Timeout   Duration `json:"timeout"`
+	Collect   []string `json:"collect"`
+
+	IssueLabels     []string `json:"issue_labels"`
+	TopContributors int      `json:"top_contributors"`
+
+	StaleGracePeriod Duration `json:"stale_grace_period"`
+
+	Concurrency           int `json:"concurrency"`
+	RepositoryConcurrency int `json:"repository_concurrency"`
+
+	client     *github.Client
+	collectors map[string]bool
+	onDemand   bool
+	metrics    *metricSet
+
+	// the repositories listed, by the key of the owner
+	seen     map[string]*seenOwner
+	seenLock sync.Mutex
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	// the HTTP caches are kept across configuration reloads, by instance name
+	caches     = map[string]httpcache.Cache{}
+	cachesLock sync.Mutex
+)
+
+func cacheFor(instance string) httpcache.Cache {
+	cachesLock.Lock()
+	defer cachesLock.Unlock()
+
+	cache, ok := caches[instance]
+	if !ok {
+		cache = httpcache.NewMemoryCache()
+		caches[instance] = cache
+	}
+
+	return cache
+}
+
+// Duration is a time.Duration that reads from JSON strings like "15m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	if parsed < 0 {
+		return fmt.Errorf("negative duration: %s", value)
+	}
+
+	d.Duration = parsed
+	return nil
+}
+
+// flagTarget returns the target configured by the command line flags.
+func flagTarget() *Target {
+	return &Target{
+		APIURL:             *apiURL,
+		UploadURL:          *uploadURL,
+		CAFile:             *caFile,
+		InsecureSkipVerify: *insecureSkipVerify,
+
+		Username:          *usernameVar,
+		Password:          *passwordVar,
+		CredentialsFile:   *credentialsFile,
+		Token:             *tokenVar,
+		TokenFile:         *tokenFile,
+		AppID:             *appID,
+		AppInstallationID: *appInstallationID,
+		AppKeyFile:        *appKeyFile,
+
+		Users: ownersOf(users),
+		Orgs:  ownersOf(orgs),
+	}
+}
+
+// loadTargets reads a list of targets from a JSON file.
+func loadTargets(path string) ([]*Target, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := decodeJSON(contents, &raw); err != nil {
+		return nil, err
+	}
+
+	if err := validate("", raw, "targets"); err != nil {
+		return nil, err
+	}
+
+	var targets []*Target
+	if err := json.Unmarshal(contents, &targets); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// init fills in the defaults from the command line flags
+// and prepares the API client for the target.
+func (t *Target) init() error {
+	if t.Name == "" {
+		if t.APIURL == "" {
+			t.Name = defaultInstance
+		} else if parsed, err := url.Parse(t.APIURL); err != nil {
+			return err
+		} else {
+			t.Name = parsed.Host
+		}
+	}
+
+	if t.SkipForks == nil {
+		// a copy, as the flag changes when the configuration is reloaded
+		value := *skipForks
+		t.SkipForks = &value
+	}
+
+	if t.Interval.Duration == 0 {
+		t.Interval.Duration = *interval
+	}
+
+	if t.Interval.Duration <= 0 {
+		return fmt.Errorf("the interval must be positive: %s", t.Interval.Duration)
+	}
+
+	if t.Timeout.Duration == 0 {
+		t.Timeout.Duration = *timeout
+	}
+
+	if t.StaleGracePeriod.Duration == 0 {
+		t.StaleGracePeriod.Duration = *staleGracePeriod
+	}
+
+	if t.Concurrency == 0 {
+		t.Concurrency = *concurrency
+	}
+
+	if t.RepositoryConcurrency == 0 {
+		t.RepositoryConcurrency = *repositoryConcurrency
+	}
+
+	if t.Collect == nil {
+		t.Collect = collect
+	}
+
+	if t.IssueLabels == nil {
+		t.IssueLabels = issueLabels
+	}
+
+	if t.TopContributors == 0 {
+		t.TopContributors = *topContributors
+	}
+
+	t.onDemand = *onDemandMode
+	t.metrics = defaultMetrics
+
+	t.collectors = map[string]bool{}
+
+	for _, name := range t.Collect {
+		if !isCollector(name) {
+			return fmt.Errorf("unknown collector: %s (available: %s)", name, strings.Join(collectorNames(), ", "))
+		}
+
+		t.collectors[name] = true
+	}
+
+	httpClient, err := t.httpClient()
+	if err != nil {
+		return err
+	}
+
+	client, err := t.githubClient(httpClient)
+	if err != nil {
+		return err
+	}
+
+	t.client = client
+	return nil
+}
+
+// collects returns whether the collector with the given name is enabled for the target.
+func (t *Target) collects(name string) bool {
+	return t.collectors[name]
+}
+
+// owners returns both the users and the organizations of the target.
+func (t *Target) owners() []Owner {
+	var owners []Owner
+
+	owners = append(owners, t.Users...)
+	owners = append(owners, t.Orgs...)
+
+	return owners
+}
+
+// probe returns a target collecting only the given owner, with the settings
+// and the client of this one, into metrics registered in the given registry.
+func (t *Target) probe(owner Owner, isOrg bool, registry prometheus.Registerer) *Target {
+	probe := &Target{
+		Name:             t.Name,
+		SkipForks:        t.SkipForks,
+		Interval:         t.Interval,
+		Timeout:          t.Timeout,
+		Collect:          t.Collect,
+		IssueLabels:      t.IssueLabels,
+		TopContributors:  t.TopContributors,
+		StaleGracePeriod: t.StaleGracePeriod,
+
+		Concurrency:           t.Concurrency,
+		RepositoryConcurrency: t.RepositoryConcurrency,
+
+		client:     t.client,
+		collectors: t.collectors,
+		metrics:    newRegisteredMetricSet(registry),
+	}
+
+	if isOrg {
+		probe.Orgs = []Owner{owner}
+	} else {
+		probe.Users = []Owner{owner}
+	}
+
+	return probe
+}
+
+// skipsForks returns whether forked repositories of the owner are excluded.
+func (t *Target) skipsForks(owner Owner) bool {
+	if owner.SkipForks != nil {
+		return *owner.SkipForks
+	}
+
+	return *t.SkipForks
+}
+
+func (t *Target) githubClient(httpClient *http.Client) (*github.Client, error) {
+	if t.APIURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+
+	if t.UploadURL == "" {
+		return github.NewEnterpriseClient(t.APIURL, t.APIURL, httpClient)
+	} else {
+		return github.NewEnterpriseClient(t.APIURL, t.UploadURL, httpClient)
+	}
+}
+
+func (t *Target) baseTransport() (http.RoundTripper, error) {
+	if t.CAFile == "" && !t.InsecureSkipVerify {
+		// use the default transport
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+
+	if t.CAFile != "" {
+		contents, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA bundle: %s", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(contents) {
+			return nil, errors.New("no certificates found in the CA bundle: " + t.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}, nil
+}
+
+func (t *Target) httpClient() (*http.Client, error) {
+	var (
+		username string
+		password string
+	)
+
+	baseTransport, err := t.baseTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheTransport := httpcache.NewTransport(cacheFor(t.Name))
+	cacheTransport.Transport = baseTransport
+
+	if t.AppID != 0 {
+		if t.AppInstallationID == 0 || t.AppKeyFile == "" {
+			return nil, errors.New("both the installation ID and the private key are required for GitHub App authentication")
+		}
+
+		transport, err := newAppTransport(t.AppID, t.AppInstallationID, t.AppKeyFile, t.Timeout.Duration, cacheTransport)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the GitHub App private key: %s", err)
+		}
+
+		if t.APIURL != "" {
+			if client, err := t.githubClient(nil); err != nil {
+				return nil, err
+			} else {
+				transport.BaseURL = client.BaseURL
+			}
+		}
+
+		return &http.Client{
+			Transport: transport,
+			Timeout:   t.Timeout.Duration,
+		}, nil
+	}
+
+	if t.TokenFile != "" || t.Token != "" {
+		transport := &tokenTransport{
+			Token:     t.Token,
+			TokenFile: t.TokenFile,
+			Transport: cacheTransport,
+		}
+
+		if _, err := transport.currentToken(); err != nil {
+			return nil, fmt.Errorf("failed to read the token file: %s", err)
+		}
+
+		return &http.Client{
+			Transport: transport,
+			Timeout:   t.Timeout.Duration,
+		}, nil
+	}
+
+	if t.CredentialsFile != "" {
+		if contents, err := ioutil.ReadFile(t.CredentialsFile); err != nil {
+			return nil, fmt.Errorf("failed to read the credentials file: %s", err)
+		} else {
+			parts := strings.SplitN(string(contents), ":", 2)
+			if len(parts) != 2 {
+				return nil, errors.New("the credentials file is not in username:password format: " + t.CredentialsFile)
+			}
+
+			username = strings.TrimSpace(parts[0])
+			password = strings.TrimSpace(parts[1])
+		}
+	} else if t.Username != "" && t.Password != "" {
+		username = t.Username
+		password = t.Password
+	}
+
+	if username != "" && password != "" {
+		return &http.Client{
+			Transport: &github.BasicAuthTransport{
+				Username:  username,
+				Password:  password,
+				Transport: cacheTransport,
+			},
+			Timeout: t.Timeout.Duration,
+		}, nil
+	} else {
+		return &http.Client{
+			Transport: cacheTransport,
+			Timeout:   t.Timeout.Duration,
+		}, nil
+	}
+}
+
+// start collects the metrics for the target now, then periodically,
+// until it is stopped.
+func (t *Target) start() {
+	if t.onDemand {
+		// the scrapes trigger the collection instead
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.cancel = cancel
+	t.done = make(chan struct{})
+
+	go func() {
+		defer close(t.done)
+
+		ticker := time.NewTicker(t.Interval.Duration)
+		defer ticker.Stop()
+
+		t.collect(ctx)
+
+		for {
+			select {
+			case <-ticker.C:
+				t.collect(ctx)
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// collect runs a collection, which is cancelled if it takes longer than the
+// interval. The snapshot of the target is only updated if it completes.
+func (t *Target) collect(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, t.Interval.Duration)
+	defer cancel()
+
+	collectStats(ctx, t)
+
+	if ctx.Err() != nil {
+		log.Println("The collection from", t.Name, "did not complete:", ctx.Err())
+		return
+	}
+
+	updateSnapshot(t)
+}
+
+// seenOwner is what the successful listings of an owner have seen.
+type seenOwner struct {
+	// the login of the owner, as the API returns it
+	Login string
+	// the time each repository was last listed
+	Repositories map[string]time.Time
+}
+
+// seenKey returns the key of the owner in the seen repositories.
+// Logins are case-insensitive, so the configured name may differ from
+// the login in the metrics labels, which the API returns.
+func seenKey(owner string) string {
+	return strings.ToLower(owner)
+}
+
+// loginOf returns the login of the owner from the last successful listing,
+// or the name as configured when there was none yet.
+func (t *Target) loginOf(owner string) string {
+	t.seenLock.Lock()
+	defer t.seenLock.Unlock()
+
+	if seen, found := t.seen[seenKey(owner)]; found {
+		return seen.Login
+	}
+
+	return owner
+}
+
+// markSeen records the repositories of a successful listing of the owner,
+// and returns the ones that were not listed for longer than the grace period.
+// These are forgotten, as if they were never seen.
+func (t *Target) markSeen(login string, repositories map[string]bool, now time.Time) []string {
+	t.seenLock.Lock()
+	defer t.seenLock.Unlock()
+
+	if t.seen == nil {
+		t.seen = map[string]*seenOwner{}
+	}
+
+	seen := t.seen[seenKey(login)]
+	if seen == nil {
+		seen = &seenOwner{Repositories: map[string]time.Time{}}
+		t.seen[seenKey(login)] = seen
+	}
+
+	seen.Login = login
+
+	var stale []string
+
+	for repository, lastSeen := range seen.Repositories {
+		if !repositories[repository] && now.Sub(lastSeen) >= t.StaleGracePeriod.Duration {
+			stale = append(stale, repository)
+			delete(seen.Repositories, repository)
+		}
+	}
+
+	for repository := range repositories {
+		seen.Repositories[repository] = now
+	}
+
+	sort.Strings(stale)
+	return stale
+}
+
+// inheritSeen takes over the repositories the previous target with the same
+// name has seen, for the owners that are still configured, so the ones that
+// disappeared shortly before a configuration reload are still forgotten.
+func (t *Target) inheritSeen(previous *Target) {
+	previous.seenLock.Lock()
+	defer previous.seenLock.Unlock()
+
+	t.seenLock.Lock()
+	defer t.seenLock.Unlock()
+
+	for _, owner := range t.owners() {
+		key := seenKey(owner.Name)
+
+		if seen, found := previous.seen[key]; found {
+			if t.seen == nil {
+				t.seen = map[string]*seenOwner{}
+			}
+
+			inherited := &seenOwner{Login: seen.Login, Repositories: map[string]time.Time{}}
+			for repository, at := range seen.Repositories {
+				inherited.Repositories[repository] = at
+			}
+
+			t.seen[key] = inherited
+		}
+	}
+}
+
+// seenRepositories returns the repositories of the owner listed within the
+// grace period, and whether the owner had a successful listing yet.
+func (t *Target) seenRepositories(owner string) (map[string]bool, bool) {
+	t.seenLock.Lock()
+	defer t.seenLock.Unlock()
+
+	seen, found := t.seen[seenKey(owner)]
+
+	repositories := map[string]bool{}
+	if found {
+		for repository := range seen.Repositories {
+			repositories[repository] = true
+		}
+	}
+
+	return repositories, found
+}
+
+// halt stops the periodic collection, cancels the current one, and waits for it to finish.
+func (t *Target) halt() {
+	if t.cancel == nil {
+		return
+	}
+
+	t.cancel()
+	<-t.done
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadTargets(t *testing.T) {
+	tf, err := ioutil.TempFile("", "gh-exporter-targets")
+	if err != nil {
+		t.Fatal("Failed to create a temporary file:", err)
+	}
+	defer os.Remove(tf.Name())
+
+	tf.WriteString(`[
+		{"users": ["rycus86"]},
+		{
+			"api_url": "https://github.example.com/api/v3/",
+			"token": "t0k3n",
+			"orgs": ["platform", "tools"],
+			"interval": "5m",
+			"skip_forks": true
+		}
+	]`)
+	tf.Close()
+
+	targets, err := loadTargets(tf.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(targets) != 2 {
+		t.Fatal("Unexpected number of targets:", len(targets))
+	}
+
+	for _, target := range targets {
+		if err := target.init(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if targets[0].Name != "api.github.com" || targets[0].Interval.Duration != *interval || *targets[0].SkipForks {
+		t.Errorf("Unexpected defaults for the first target: %+v", targets[0])
+	}
+
+	if targets[1].Name != "github.example.com" || targets[1].Interval.Duration != 5*time.Minute || !*targets[1].SkipForks {
+		t.Errorf("Unexpected settings for the second target: %+v", targets[1])
+	}
+
+	if targets[1].client.BaseURL.String() != "https://github.example.com/api/v3/" {
+		t.Error("Unexpected API URL:", targets[1].client.BaseURL)
+	}
+}
+
+func TestLoadTargetsWithInvalidInterval(t *testing.T) {
+	tf, err := ioutil.TempFile("", "gh-exporter-targets")
+	if err != nil {
+		t.Fatal("Failed to create a temporary file:", err)
+	}
+	defer os.Remove(tf.Name())
+
+	tf.WriteString(`[{"users": ["rycus86"], "interval": "often"}]`)
+	tf.Close()
+
+	if _, err := loadTargets(tf.Name()); err == nil {
+		t.Error("Expected the invalid interval to fail")
+	}
+
+	var negative Duration
+	if err := negative.UnmarshalJSON([]byte(`"-5m"`)); err == nil {
+		t.Error("Expected the negative interval to fail")
+	}
+}
+
+func TestTargetWithNonPositiveInterval(t *testing.T) {
+	original := *interval
+	defer func() { *interval = original }()
+
+	*interval = 0
+
+	for _, value := range []time.Duration{0, -5 * time.Minute} {
+		target := &Target{Users: []Owner{{Name: "rycus86"}}, Interval: Duration{value}}
+
+		if err := target.init(); err == nil {
+			t.Error("Expected an error for the interval:", value)
+		}
+	}
+}
+
+func TestCollectStatsForMultipleTargets(t *testing.T) {
+	repoCount.Reset()
+
+	data, err := ioutil.ReadFile("testdata/org_repos.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	httpmock.RegisterResponder(
+		"GET", "https://api.github.com/orgs/docker/repos",
+		httpmock.NewBytesResponder(200, data))
+	httpmock.RegisterResponder(
+		"GET", "https://github.example.com/api/v3/orgs/docker/repos",
+		httpmock.NewBytesResponder(200, []byte("[]")))
+
+	public := &Target{Orgs: []Owner{{Name: "docker"}}}
+	enterprise := &Target{Name: "ghe", APIURL: "https://github.example.com/api/v3/", Orgs: []Owner{{Name: "docker"}}}
+
+	for _, target := range []*Target{public, enterprise} {
+		if err := target.init(); err != nil {
+			t.Fatal(err)
+		}
+
+		collectStats(context.Background(), target)
+	}
+
+	gathered, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]float64{}
+
+	for _, g := range gathered {
+		if g.GetName() != "github_repo_count" {
+			continue
+		}
+
+		for _, m := range g.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "instance" {
+					counts[label.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+
+	if len(counts) != 2 || counts["api.github.com"] != 30 || counts["ghe"] != 0 {
+		t.Error("Unexpected repository counts:", counts)
+	}
+}
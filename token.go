@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenTransport sends a personal access token or OAuth token
+// in the Authorization header of every API call.
+// When the token is read from a file, the file is read again whenever
+// it changes on disk, so rotated secrets are picked up without a restart.
+type tokenTransport struct {
+	Token     string
+	TokenFile string
+
+	Transport http.RoundTripper
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.currentToken()
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := cloneRequest(req)
+	authenticated.Header.Set("Authorization", "token "+token)
+
+	return t.transport().RoundTrip(authenticated)
+}
+
+func (t *tokenTransport) currentToken() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.TokenFile == "" {
+		return t.Token, nil
+	}
+
+	info, err := os.Stat(t.TokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	if t.Token != "" && info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return t.Token, nil
+	}
+
+	contents, err := ioutil.ReadFile(t.TokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(contents))
+	if token == "" {
+		return "", errors.New("the token file is empty: " + t.TokenFile)
+	}
+
+	t.Token = token
+	t.modTime = info.ModTime()
+	t.size = info.Size()
+
+	return t.Token, nil
+}
+
+func (t *tokenTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+
+	return http.DefaultTransport
+}
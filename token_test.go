@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAuthenticatedClientByToken(t *testing.T) {
+	*tokenVar = "s3cr3t-t0k3n"
+	defer func() { *tokenVar = "" }()
+
+	client, err := flagTarget().httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tp, ok := client.Transport.(*tokenTransport); !ok {
+		t.Errorf("Unexpected API client transport: %T\n", client.Transport)
+	} else if tp.Token != "s3cr3t-t0k3n" {
+		t.Errorf("Invalid token found: %s", tp.Token)
+	}
+}
+
+func TestTokenFileIsReadAgainWhenChanged(t *testing.T) {
+	tf, err := ioutil.TempFile("", "gh-exporter-token")
+	if err != nil {
+		t.Fatal("Failed to create a temporary file:", err)
+	}
+	defer os.Remove(tf.Name())
+
+	tf.WriteString("first-token\n")
+	tf.Close()
+
+	var lastSeen string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastSeen = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &tokenTransport{TokenFile: tf.Name()}}
+
+	if resp, err := client.Get(server.URL); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+
+	if lastSeen != "token first-token" {
+		t.Error("Unexpected authorization header:", lastSeen)
+	}
+
+	if err := ioutil.WriteFile(tf.Name(), []byte("rotated-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// make sure the change is visible even on filesystems with coarse timestamps
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(tf.Name(), later, later)
+
+	if resp, err := client.Get(server.URL); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+
+	if lastSeen != "token rotated-token" {
+		t.Error("Unexpected authorization header after rotation:", lastSeen)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	trafficViews = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_views_total",
+		Help:      "Number of Views in the last 14 days",
+	}, []string{"instance", "owner", "repository"})
+	trafficUniqueViews = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_views_unique",
+		Help:      "Number of Unique Visitors in the last 14 days",
+	}, []string{"instance", "owner", "repository"})
+	trafficClones = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_clones_total",
+		Help:      "Number of Clones in the last 14 days",
+	}, []string{"instance", "owner", "repository"})
+	trafficUniqueClones = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_clones_unique",
+		Help:      "Number of Unique Cloners in the last 14 days",
+	}, []string{"instance", "owner", "repository"})
+
+	trafficReferrerViews = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_top_referrer_views_total",
+		Help:      "Number of Views from the Top Referrers in the last 14 days",
+	}, []string{"instance", "owner", "repository", "referrer"})
+	trafficReferrerUniqueViews = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_top_referrer_views_unique",
+		Help:      "Number of Unique Visitors from the Top Referrers in the last 14 days",
+	}, []string{"instance", "owner", "repository", "referrer"})
+	trafficPathViews = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_top_path_views_total",
+		Help:      "Number of Views of the Popular Content in the last 14 days",
+	}, []string{"instance", "owner", "repository", "path"})
+	trafficPathUniqueViews = newGaugeVec(prometheus.GaugeOpts{
+		Namespace: "github",
+		Name:      "traffic_top_path_views_unique",
+		Help:      "Number of Unique Visitors of the Popular Content in the last 14 days",
+	}, []string{"instance", "owner", "repository", "path"})
+)
+
+// collectTraffic collects the traffic statistics of the repository,
+// which requires push access to it.
+func collectTraffic(ctx context.Context, target *Target, repository *github.Repository) error {
+	owner, name := repository.GetOwner().GetLogin(), repository.GetName()
+	labels := repositoryLabels(target, repository)
+
+	views, _, err := target.client.Repositories.ListTrafficViews(ctx, owner, name, nil)
+	if err != nil {
+		return err
+	}
+
+	target.gauge(trafficViews).WithLabelValues(labels...).Set(float64(views.GetCount()))
+	target.gauge(trafficUniqueViews).WithLabelValues(labels...).Set(float64(views.GetUniques()))
+
+	clones, _, err := target.client.Repositories.ListTrafficClones(ctx, owner, name, nil)
+	if err != nil {
+		return err
+	}
+
+	target.gauge(trafficClones).WithLabelValues(labels...).Set(float64(clones.GetCount()))
+	target.gauge(trafficUniqueClones).WithLabelValues(labels...).Set(float64(clones.GetUniques()))
+
+	referrers, _, err := target.client.Repositories.ListTrafficReferrers(ctx, owner, name)
+	if err != nil {
+		return err
+	}
+
+	referrerViews := target.updateSeries(trafficReferrerViews, repositorySeries(target, repository))
+	referrerUniqueViews := target.updateSeries(trafficReferrerUniqueViews, repositorySeries(target, repository))
+
+	for _, referrer := range referrers {
+		referrerLabels := append(labels, referrer.GetReferrer())
+
+		referrerViews.WithLabelValues(referrerLabels...).Set(float64(referrer.GetCount()))
+		referrerUniqueViews.WithLabelValues(referrerLabels...).Set(float64(referrer.GetUniques()))
+	}
+
+	referrerViews.done()
+	referrerUniqueViews.done()
+
+	paths, _, err := target.client.Repositories.ListTrafficPaths(ctx, owner, name)
+	if err != nil {
+		return err
+	}
+
+	pathViews := target.updateSeries(trafficPathViews, repositorySeries(target, repository))
+	pathUniqueViews := target.updateSeries(trafficPathUniqueViews, repositorySeries(target, repository))
+
+	for _, path := range paths {
+		pathLabels := append(labels, path.GetPath())
+
+		pathViews.WithLabelValues(pathLabels...).Set(float64(path.GetCount()))
+		pathUniqueViews.WithLabelValues(pathLabels...).Set(float64(path.GetUniques()))
+	}
+
+	pathViews.done()
+	pathUniqueViews.done()
+
+	return nil
+}
+
+func init() {
+	register(trafficViews)
+	register(trafficUniqueViews)
+	register(trafficClones)
+	register(trafficUniqueClones)
+	register(trafficReferrerViews)
+	register(trafficReferrerUniqueViews)
+	register(trafficPathViews)
+	register(trafficPathUniqueViews)
+
+	addRepositoryCollector(RepositoryCollector{Name: "traffic", Collect: collectTraffic})
+}
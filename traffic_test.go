@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/jarcoal/httpmock.v1"
+	"testing"
+)
+
+func TestCollectTraffic(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	base := "https://api.github.com/repos/rycus86/podlike/traffic"
+
+	httpmock.RegisterResponder("GET", base+"/views",
+		httpmock.NewStringResponder(200, `{"count": 120, "uniques": 35}`))
+	httpmock.RegisterResponder("GET", base+"/clones",
+		httpmock.NewStringResponder(200, `{"count": 14, "uniques": 6}`))
+	httpmock.RegisterResponder("GET", base+"/popular/referrers",
+		httpmock.NewStringResponder(200, `[{"referrer": "google.com", "count": 40, "uniques": 12}]`))
+	httpmock.RegisterResponder("GET", base+"/popular/paths",
+		httpmock.NewStringResponder(200, `[{"path": "/rycus86/podlike", "title": "podlike", "count": 80, "uniques": 20}]`))
+
+	// the token has no push access to the other repository
+	httpmock.RegisterResponder("GET", "https://api.github.com/repos/rycus86/forked/traffic/views",
+		httpmock.NewStringResponder(403, `{"message": "Must have push access to repository"}`))
+
+	collectWith(t, "traffic")
+
+	labels := prometheus.Labels{"owner": "rycus86", "repository": "podlike"}
+
+	expectGauge(t, "github_traffic_views_total", labels, 120)
+	expectGauge(t, "github_traffic_views_unique", labels, 35)
+	expectGauge(t, "github_traffic_clones_total", labels, 14)
+	expectGauge(t, "github_traffic_clones_unique", labels, 6)
+	expectGauge(t, "github_traffic_top_referrer_views_total",
+		prometheus.Labels{"repository": "podlike", "referrer": "google.com"}, 40)
+	expectGauge(t, "github_traffic_top_path_views_unique",
+		prometheus.Labels{"repository": "podlike", "path": "/rycus86/podlike"}, 20)
+
+	if _, found := gaugeValue(t, "github_traffic_views_total", prometheus.Labels{"repository": "forked"}); found {
+		t.Error("Unexpected traffic metrics for the repository without access")
+	}
+}
+
+func TestTrafficReferrersAreReplaced(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	base := "https://api.github.com/repos/rycus86/podlike/traffic"
+
+	httpmock.RegisterResponder("GET", base+"/views", httpmock.NewStringResponder(200, `{"count": 120, "uniques": 35}`))
+	httpmock.RegisterResponder("GET", base+"/clones", httpmock.NewStringResponder(200, `{"count": 14, "uniques": 6}`))
+	httpmock.RegisterResponder("GET", base+"/popular/paths", httpmock.NewStringResponder(200, `[]`))
+	httpmock.RegisterResponder("GET", base+"/popular/referrers",
+		httpmock.NewStringResponder(200, `[{"referrer": "google.com", "count": 40, "uniques": 12}]`))
+
+	collectWith(t, "traffic")
+
+	httpmock.RegisterResponder("GET", base+"/popular/referrers",
+		httpmock.NewStringResponder(200, `[{"referrer": "github.com", "count": 25, "uniques": 9}]`))
+
+	collectWith(t, "traffic")
+
+	expectGauge(t, "github_traffic_top_referrer_views_total",
+		prometheus.Labels{"repository": "podlike", "referrer": "github.com"}, 25)
+
+	if _, found := gaugeValue(t, "github_traffic_top_referrer_views_total",
+		prometheus.Labels{"repository": "podlike", "referrer": "google.com"}); found {
+		t.Error("The referrer of the previous collection should be removed")
+	}
+}